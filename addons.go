@@ -0,0 +1,425 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// a set of dlls installed into a prefix over the builtin ones
+// of wine (dxvk, vkd3d), recorded in the settings of the prefix
+type Addon struct {
+	Name    string
+	Version string
+	Dlls    []string
+}
+
+// the addons that can be installed and the name their archives start with
+var addonArchives = map[string]string{
+	"dxvk":  "dxvk-",
+	"vkd3d": "vkd3d-proton-",
+}
+
+// read an addon as written in the settings file of a
+// prefix, its name, version and the dlls it installed
+func parseAddon(value string) (ret Addon, retErr error) {
+	var fields = strings.Fields(value)
+	if len(fields) < 2 {
+		return ret, fmt.Errorf("addon %q: needs a name and a version", value)
+	}
+
+	ret.Name = fields[0]
+	ret.Version = fields[1]
+	ret.Dlls = fields[2:]
+
+	return
+}
+
+// write an addon the way it is read
+func (a Addon) String() string {
+	return strings.Join(append([]string{a.Name, a.Version}, a.Dlls...), " ")
+}
+
+// get the addon of a name installed into a prefix
+func (c PrefixConfig) addon(name string) (Addon, bool) {
+	for _, a := range c.Addons {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Addon{}, false
+}
+
+// get whether a prefix runs 64 bit programs, as noted at the top
+// of its registry or else by it having a dir for 32 bit dlls
+func prefixIs64Bit(prefixDir string) bool {
+	var regFile, readErr = readRegFile(path.Join(prefixDir, "system.reg"))
+	if readErr == nil {
+		for _, line := range regFile.Header {
+			if strings.HasPrefix(line, "#arch=") {
+				return strings.TrimPrefix(line, "#arch=") == "win64"
+			}
+		}
+	}
+
+	var _, statErr = os.Stat(path.Join(prefixDir, "drive_c", "windows", "syswow64"))
+	return statErr == nil
+}
+
+// get the dirs of the prefix the dlls of each architecture go to,
+// on 64 bit prefixes the 32 bit dlls go to syswow64
+func addonTargetDirs(prefixDir string) map[string]string {
+	if prefixIs64Bit(prefixDir) {
+		return map[string]string{
+			"x64": "system32",
+			"x32": "syswow64",
+		}
+	}
+	return map[string]string{
+		"x32": "system32",
+	}
+}
+
+// get the version of an addon from the name of its archive
+// (dxvk-2.3.tar.gz is version 2.3 of dxvk)
+func addonVersion(name string, archive string) string {
+	var base = path.Base(archive)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar.xz", ".tar.zst", ".tar", ".zip"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	return strings.TrimPrefix(base, addonArchives[name])
+}
+
+// extract an archive into a dir, tar and zip archives are read
+// here and anything else (like .tar.zst) is given to the tar program
+func extractArchive(archive string, dir string) error {
+	switch {
+	case strings.HasSuffix(archive, ".zip"):
+		return extractZip(archive, dir)
+	case strings.HasSuffix(archive, ".tar.gz"), strings.HasSuffix(archive, ".tgz"), strings.HasSuffix(archive, ".tar"):
+		return extractTar(archive, dir)
+	default:
+		var output, tarErr = exec.Command("tar", "-xf", archive, "-C", dir).CombinedOutput()
+		if tarErr != nil {
+			return fmt.Errorf("%s: %s %s", archive, tarErr.Error(), strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+}
+
+// get where a file of an archive is extracted to,
+// refusing files that would end up outside the dir
+func extractedPath(dir string, name string) (string, error) {
+	var target = filepath.Join(dir, name)
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s: outside of the archive", name)
+	}
+	return target, nil
+}
+
+// write a file read from an archive
+func writeExtracted(target string, reader io.Reader) error {
+	os.MkdirAll(path.Dir(target), 0755)
+
+	var file, createErr = os.Create(target)
+	if createErr != nil {
+		return createErr
+	}
+	defer file.Close()
+
+	var _, copyErr = io.Copy(file, reader)
+	return copyErr
+}
+
+// extract a tar archive, compressed with gzip or not
+func extractTar(archive string, dir string) error {
+	var file, openErr = os.Open(archive)
+	if openErr != nil {
+		return openErr
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if !strings.HasSuffix(archive, ".tar") {
+		var gzipReader, gzipErr = gzip.NewReader(file)
+		if gzipErr != nil {
+			return fmt.Errorf("%s: %s", archive, gzipErr.Error())
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	var tarReader = tar.NewReader(reader)
+	for {
+		var header, nextErr = tarReader.Next()
+		if nextErr == io.EOF {
+			return nil
+		} else if nextErr != nil {
+			return fmt.Errorf("%s: %s", archive, nextErr.Error())
+		}
+
+		// only files are needed
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var target, pathErr = extractedPath(dir, header.Name)
+		if pathErr != nil {
+			return fmt.Errorf("%s: %s", archive, pathErr.Error())
+		}
+
+		if writeErr := writeExtracted(target, tarReader); writeErr != nil {
+			return writeErr
+		}
+	}
+}
+
+// extract a zip archive
+func extractZip(archive string, dir string) error {
+	var zipReader, openErr = zip.OpenReader(archive)
+	if openErr != nil {
+		return openErr
+	}
+	defer zipReader.Close()
+
+	for _, zipFile := range zipReader.File {
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+
+		var target, pathErr = extractedPath(dir, zipFile.Name)
+		if pathErr != nil {
+			return fmt.Errorf("%s: %s", archive, pathErr.Error())
+		}
+
+		var reader, readErr = zipFile.Open()
+		if readErr != nil {
+			return readErr
+		}
+		var writeErr = writeExtracted(target, reader)
+		reader.Close()
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return nil
+}
+
+// find the dirs of dlls of each architecture in an extracted
+// archive, named x64 for 64 bit and x32 or x86 for 32 bit
+func addonDllDirs(dir string) map[string]string {
+	var found = make(map[string]string)
+
+	filepath.Walk(dir, func(filePath string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil || !info.IsDir() {
+			return nil
+		}
+
+		switch info.Name() {
+		case "x64":
+			found["x64"] = filePath
+		case "x32", "x86":
+			found["x32"] = filePath
+		}
+		return nil
+	})
+
+	return found
+}
+
+// get the dlls in a dir
+func dllsInDir(dir string) (retList []string) {
+	var entries, _ = ioutil.ReadDir(dir)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".dll") {
+			retList = append(retList, entry.Name())
+		}
+	}
+	return
+}
+
+// get the dir the dlls of wine an addon replaced are kept in
+func addonBackupDir(prefixDir string, name string) string {
+	return path.Join(prefixDir, ".winela", "addons", name)
+}
+
+// copy a file, keeping its mode
+func copyFile(source string, target string) error {
+	var data, readErr = ioutil.ReadFile(source)
+	if readErr != nil {
+		return readErr
+	}
+
+	var mode = os.FileMode(0644)
+	if info, statErr := os.Stat(source); statErr == nil {
+		mode = info.Mode()
+	}
+
+	os.MkdirAll(path.Dir(target), 0755)
+	return ioutil.WriteFile(target, data, mode)
+}
+
+// install the dlls of an addon from its archive into the dirs
+// of the prefix, keeping the dlls they replace to put back when
+// uninstalling, then record it and make wine use the native dlls,
+// the archive is checked to have dlls for every dir before the
+// prefix is touched and what was copied is put back on failure
+func installAddon(prefixDir string, config *PrefixConfig, name string, archive string) (ret Addon, retErr error) {
+	if _, known := addonArchives[name]; !known {
+		return ret, fmt.Errorf("addon %s: not dxvk or vkd3d", name)
+	}
+
+	if _, statErr := os.Stat(path.Join(prefixDir, "drive_c", "windows", "system32")); statErr != nil {
+		return ret, fmt.Errorf("prefix %s: has no system32 dir", prefixDir)
+	}
+
+	var tempDir, tempErr = ioutil.TempDir("", "winela-"+name)
+	if tempErr != nil {
+		return ret, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	if extractErr := extractArchive(archive, tempDir); extractErr != nil {
+		return ret, extractErr
+	}
+
+	var dllDirs = addonDllDirs(tempDir)
+	var targetDirs = addonTargetDirs(prefixDir)
+
+	// the dir of the archive each dir of the prefix gets its dlls
+	// from, every one needing some, sorted to copy them in order
+	var systemDirs []string
+	var sourceOf = make(map[string]string)
+	for arch, systemDir := range targetDirs {
+		var sourceDir, found = dllDirs[arch]
+		if !found || len(dllsInDir(sourceDir)) == 0 {
+			return ret, fmt.Errorf("%s: has no %s dlls", archive, arch)
+		}
+		systemDirs = append(systemDirs, systemDir)
+		sourceOf[systemDir] = sourceDir
+	}
+	sort.Strings(systemDirs)
+
+	// a previous version is taken out first
+	if _, installed := config.addon(name); installed {
+		if uninstallErr := uninstallAddon(prefixDir, config, name); uninstallErr != nil {
+			return ret, uninstallErr
+		}
+	}
+
+	ret.Name = name
+	ret.Version = addonVersion(name, archive)
+
+	// the dlls copied so far, put back if one can't be
+	var copied []string
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for _, target := range copied {
+			var backup = path.Join(addonBackupDir(prefixDir, name), path.Base(path.Dir(target)), path.Base(target))
+			if _, statErr := os.Stat(backup); statErr == nil {
+				copyFile(backup, target)
+			} else {
+				os.Remove(target)
+			}
+		}
+		os.RemoveAll(addonBackupDir(prefixDir, name))
+	}()
+
+	var seen = make(map[string]bool)
+	for _, systemDir := range systemDirs {
+		var sourceDir = sourceOf[systemDir]
+		for _, dll := range dllsInDir(sourceDir) {
+			var target = path.Join(prefixDir, "drive_c", "windows", systemDir, dll)
+			var backup = path.Join(addonBackupDir(prefixDir, name), systemDir, dll)
+
+			// keep the dll of wine being replaced
+			if _, statErr := os.Stat(target); statErr == nil {
+				if copyErr := copyFile(target, backup); copyErr != nil {
+					return ret, copyErr
+				}
+			}
+
+			copied = append(copied, target)
+			if copyErr := copyFile(path.Join(sourceDir, dll), target); copyErr != nil {
+				return ret, copyErr
+			}
+
+			var dllName = strings.TrimSuffix(strings.ToLower(dll), ".dll")
+			if !seen[dllName] {
+				seen[dllName] = true
+				ret.Dlls = append(ret.Dlls, dllName)
+			}
+		}
+	}
+	sort.Strings(ret.Dlls)
+
+	// the installed dlls are only used when wine prefers native ones
+	for _, dll := range ret.Dlls {
+		config.Dlls = setDllOverride(config.Dlls, DllOverride{Dll: dll, Order: "n"})
+	}
+	config.Addons = append(config.Addons, ret)
+
+	return
+}
+
+// take the dlls of an addon out of a prefix, putting back the dlls
+// of wine they replaced and removing the overrides and the record
+func uninstallAddon(prefixDir string, config *PrefixConfig, name string) error {
+	var installed, found = config.addon(name)
+	if !found {
+		return fmt.Errorf("addon %s: not installed in %s", name, prefixDir)
+	}
+
+	var backupDir = addonBackupDir(prefixDir, name)
+	for _, systemDir := range []string{"system32", "syswow64"} {
+		for _, dll := range installed.Dlls {
+			// dlls are named in lower case when recorded
+			var matches, _ = filepath.Glob(path.Join(prefixDir, "drive_c", "windows", systemDir, "*"))
+			for _, target := range matches {
+				if strings.ToLower(path.Base(target)) != dll+".dll" {
+					continue
+				}
+
+				var backup = path.Join(backupDir, systemDir, path.Base(target))
+				if _, statErr := os.Stat(backup); statErr == nil {
+					if copyErr := copyFile(backup, target); copyErr != nil {
+						return copyErr
+					}
+					continue
+				}
+
+				if removeErr := os.Remove(target); removeErr != nil {
+					return removeErr
+				}
+			}
+		}
+	}
+
+	os.RemoveAll(backupDir)
+
+	for _, dll := range installed.Dlls {
+		config.Dlls = unsetDllOverride(config.Dlls, dll)
+	}
+
+	var kept []Addon
+	for _, a := range config.Addons {
+		if a.Name != name {
+			kept = append(kept, a)
+		}
+	}
+	config.Addons = kept
+
+	return nil
+}
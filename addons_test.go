@@ -0,0 +1,173 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// make a release archive of dxvk with a few dlls for each architecture
+func makeDxvkArchive(archive string) {
+	makeAddonArchive(archive, []string{"x64/d3d9.dll", "x64/dxgi.dll", "x32/d3d9.dll", "x32/dxgi.dll"})
+}
+
+// make a release archive of dxvk with the given files
+func makeAddonArchive(archive string, names []string) {
+	var file, _ = os.Create(archive)
+	defer file.Close()
+
+	var gzipWriter = gzip.NewWriter(file)
+	defer gzipWriter.Close()
+	var tarWriter = tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	for _, name := range names {
+		var content = []byte("dxvk " + name)
+		tarWriter.WriteHeader(&tar.Header{
+			Name:     "dxvk-2.3/" + name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		tarWriter.Write(content)
+	}
+}
+
+// make a prefix for 64 bit programs with a dll of wine in each system dir
+func makeDllPrefix(prefixDir string) {
+	os.MkdirAll(prefixDir+"/drive_c/windows/system32", 0755)
+	os.MkdirAll(prefixDir+"/drive_c/windows/syswow64", 0755)
+	os.WriteFile(prefixDir+"/system.reg", []byte("WINE REGISTRY Version 2\n#arch=win64\n"), 0644)
+	os.WriteFile(prefixDir+"/drive_c/windows/system32/d3d9.dll", []byte("wine 64"), 0644)
+	os.WriteFile(prefixDir+"/drive_c/windows/syswow64/d3d9.dll", []byte("wine 32"), 0644)
+}
+
+func TestAddonVersion(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamName    string
+		ParamArchive string
+	}{
+		{
+			Description: "dxvk archive",
+			Expected:    "2.3",
+
+			ParamName:    "dxvk",
+			ParamArchive: "/downloads/dxvk-2.3.tar.gz",
+		},
+		{
+			Description: "vkd3d archive",
+			Expected:    "2.11",
+
+			ParamName:    "vkd3d",
+			ParamArchive: "vkd3d-proton-2.11.tar.zst",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = addonVersion(testCase.ParamName, testCase.ParamArchive)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestInstallUninstallAddon(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	var archive = inTestDir("dxvk-2.3.tar.gz")
+	makeDllPrefix(prefixDir)
+	makeDxvkArchive(archive)
+
+	var config PrefixConfig
+	var installed, installErr = installAddon(prefixDir, &config, "dxvk", archive)
+
+	if installErr != nil {
+		errorExpGot(t, nil, installErr, true)
+	}
+
+	var expected = "[dxvk 2.3 d3d9 dxgi] [d3d9 n dxgi n]"
+	if gotten := fmt.Sprint(config.Addons, " ", config.Dlls); gotten != expected || installed.String() != "dxvk 2.3 d3d9 dxgi" {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	for file, content := range map[string]string{
+		"system32/d3d9.dll": "dxvk x64/d3d9.dll",
+		"syswow64/dxgi.dll": "dxvk x32/dxgi.dll",
+	} {
+		if data, _ := ioutil.ReadFile(prefixDir + "/drive_c/windows/" + file); string(data) != content {
+			errorExpGot(t, content, string(data), false)
+		}
+	}
+
+	var uninstallErr = uninstallAddon(prefixDir, &config, "dxvk")
+
+	if uninstallErr != nil {
+		errorExpGot(t, nil, uninstallErr, true)
+	}
+
+	if len(config.Addons) != 0 || len(config.Dlls) != 0 {
+		errorExpGot(t, PrefixConfig{}, config, false)
+	}
+
+	// the dlls of wine are back and the others gone
+	if data, _ := ioutil.ReadFile(prefixDir + "/drive_c/windows/syswow64/d3d9.dll"); string(data) != "wine 32" {
+		errorExpGot(t, "wine 32", string(data), false)
+	}
+
+	if _, statErr := os.Stat(prefixDir + "/drive_c/windows/system32/dxgi.dll"); !os.IsNotExist(statErr) {
+		errorExpGot(t, "no dxgi.dll", statErr, true)
+	}
+}
+
+func TestInstallAddonMissingArch(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	var archive = inTestDir("dxvk-2.3.tar.gz")
+	var broken = inTestDir("dxvk-2.4.tar.gz")
+	makeDllPrefix(prefixDir)
+	makeDxvkArchive(archive)
+	makeAddonArchive(broken, []string{"x64/d3d9.dll", "x64/dxgi.dll"})
+
+	var config PrefixConfig
+	installAddon(prefixDir, &config, "dxvk", archive)
+
+	// a reinstall from an archive with no 32 bit dlls
+	var _, installErr = installAddon(prefixDir, &config, "dxvk", broken)
+
+	var expectedErr = fmt.Errorf("%s: has no x32 dlls", broken)
+	if equalErrorList(t, []error{expectedErr}, []error{installErr}) == false {
+		errorExpGot(t, expectedErr, installErr, true)
+	}
+
+	// leaves the installed version as it was, which still uninstalls
+	if gotten := fmt.Sprint(config.Addons); gotten != "[dxvk 2.3 d3d9 dxgi]" {
+		errorExpGot(t, "[dxvk 2.3 d3d9 dxgi]", gotten, false)
+	}
+
+	uninstallAddon(prefixDir, &config, "dxvk")
+	if data, _ := ioutil.ReadFile(prefixDir + "/drive_c/windows/system32/d3d9.dll"); string(data) != "wine 64" {
+		errorExpGot(t, "wine 64", string(data), false)
+	}
+}
+
+func TestExtractedPath(t *testing.T) {
+	var _, gottenErr = extractedPath("dir", "../../etc/passwd")
+	var expectedErr = fmt.Errorf("../../etc/passwd: outside of the archive")
+
+	if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+		errorExpGot(t, expectedErr, gottenErr, true)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// the WINEDEBUG channels of the debug presets winela knows
+var builtinDebugPresets = map[string]string{
+	"relay-lite": "+tid,+seh,+relay,-heap",
+	"seh":        "+tid,+seh",
+	"loaddll":    "+loaddll",
+	"d3d":        "+tid,+d3d,+d3d11,+dxgi,+vulkan",
+	"file":       "+tid,+file",
+}
+
+// the class and channel of a line of wine debug output and the
+// message after the function name (0024:fixme:d3d:func message)
+var debugLinePattern = regexp.MustCompile(`(?:^|:)(trace|fixme|err|warn):([A-Za-z0-9_]+):\S*\s*(.*)`)
+
+// a channel as given in WINEDEBUG (+relay, -heap, err+all)
+var debugChannelPattern = regexp.MustCompile(`^(trace|fixme|err|warn)?[+-][A-Za-z0-9_]+$`)
+
+// get the channels of a debug preset, the ones of the
+// config over the ones winela knows
+func (r Runner) debugPreset(name string) (string, bool) {
+	if channels, found := r.DebugPresets[name]; found {
+		return channels, true
+	}
+	var channels, found = builtinDebugPresets[name]
+	return channels, found
+}
+
+// get the names of all debug presets
+func (r Runner) debugPresetNames() (retList []string) {
+	var seen = make(map[string]bool)
+	for _, presets := range []map[string]string{builtinDebugPresets, r.DebugPresets} {
+		for name := range presets {
+			if !seen[name] {
+				seen[name] = true
+				retList = append(retList, name)
+			}
+		}
+	}
+	sort.Strings(retList)
+	return
+}
+
+// counts of how often channels and messages came
+// up in debug output, with how many lines there were
+type DebugSummary struct {
+	Lines    int
+	Channels map[string]int
+	Messages map[string]int
+}
+
+// a writer compressing debug output into a file and
+// counting its channels and messages line by line
+type debugLogWriter struct {
+	compressed *gzip.Writer
+	partial    []byte
+	summary    DebugSummary
+}
+
+func newDebugLogWriter(file *os.File) *debugLogWriter {
+	return &debugLogWriter{
+		compressed: gzip.NewWriter(file),
+		summary: DebugSummary{
+			Channels: make(map[string]int),
+			Messages: make(map[string]int),
+		},
+	}
+}
+
+func (w *debugLogWriter) Write(data []byte) (int, error) {
+	w.partial = append(w.partial, data...)
+
+	for {
+		var end = bytes.IndexByte(w.partial, '\n')
+		if end == -1 {
+			break
+		}
+		w.countLine(string(w.partial[:end]))
+		w.partial = w.partial[end+1:]
+	}
+
+	return w.compressed.Write(data)
+}
+
+// count the channel of a line, and its message for errors and
+// fixmes (as traces are too many and too different to be of use)
+func (w *debugLogWriter) countLine(line string) {
+	w.summary.Lines++
+
+	var match = debugLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	w.summary.Channels[match[1]+":"+match[2]]++
+	if match[1] == "err" || match[1] == "fixme" {
+		w.summary.Messages[match[1]+":"+match[2]+" "+match[3]]++
+	}
+}
+
+// finish the compressed file, counting what is left
+func (w *debugLogWriter) Close() error {
+	if len(w.partial) > 0 {
+		w.countLine(string(w.partial))
+		w.partial = nil
+	}
+	return w.compressed.Close()
+}
+
+// get the most frequent keys of counts, at most limit of them
+func mostFrequent(counts map[string]int, limit int) (retList []string) {
+	for key := range counts {
+		retList = append(retList, key)
+	}
+
+	sort.Slice(retList, func(i, j int) bool {
+		if counts[retList[i]] != counts[retList[j]] {
+			return counts[retList[i]] > counts[retList[j]]
+		}
+		return retList[i] < retList[j]
+	})
+
+	if len(retList) > limit {
+		retList = retList[:limit]
+	}
+	return
+}
+
+// return the summary as lines of text, the most
+// frequent channels and messages with their counts
+func (s DebugSummary) String() (ret string) {
+	ret += fmt.Sprintf("%v lines\n", s.Lines)
+
+	for _, channel := range mostFrequent(s.Channels, 5) {
+		ret += fmt.Sprintf("%8v %s\n", s.Channels[channel], channel)
+	}
+
+	if len(s.Messages) > 0 {
+		ret += "most frequent errors and fixmes:\n"
+	}
+	for _, message := range mostFrequent(s.Messages, 5) {
+		ret += fmt.Sprintf("%8v %s\n", s.Messages[message], message)
+	}
+
+	return
+}
+
+// run an exe of the list without forking with WINEDEBUG channels
+// on, putting all of its output into a compressed log instead of
+// the terminal, returns how it ended and a summary of the output
+func (r Runner) debugFromList(elementNumber int, channels string) (ret RunResult, summary DebugSummary, retErr error) {
+	var targetExe, findErr = r.exeByNumber(elementNumber)
+	if findErr != nil {
+		return ret, summary, findErr
+	}
+
+	// it is a launch limited the same way, which is made next to
+	// the exe running as well so it takes none of its locks
+	var left, limited, admitErr = r.admitTime(targetExe)
+	if admitErr != nil {
+		return ret, summary, admitErr
+	}
+
+	// and checked to be the exe added the same way
+	if verifyErr := r.verifyBeforeLaunch(targetExe, nil, os.Stderr); verifyErr != nil {
+		return ret, summary, verifyErr
+	}
+
+	var prefixConfig, restorePrefix, prepareErr = r.preparePrefix(targetExe, true)
+	if prepareErr != nil {
+		return ret, summary, prepareErr
+	}
+	defer restorePrefix()
+
+	var commandToRun = r.makeCommand(r.commandArgs(targetExe), append(r.commandEnv(targetExe, prefixConfig), "WINEDEBUG="+channels))
+
+	// the log is kept with the other logs of the entry
+	var startTime = time.Now()
+	var logDir = r.logDir(targetExe)
+	if r.StateDir == "" {
+		logDir = os.TempDir()
+	}
+	os.MkdirAll(logDir, 0755)
+
+	var logFile, createErr = os.Create(path.Join(logDir, startTime.Format("20060102-150405.000")+".debug.log.gz"))
+	if createErr != nil {
+		return ret, summary, fmt.Errorf("could not make log: %s", createErr.Error())
+	}
+	defer logFile.Close()
+
+	// making room for it the way launches do
+	if r.StateDir != "" {
+		var keep, size = r.logLimits()
+		pruneLogs(logDir, keep, size)
+	}
+
+	// output and errors go through the same writer to keep their order
+	var logWriter = newDebugLogWriter(logFile)
+	commandToRun.Stdout = logWriter
+	commandToRun.Stderr = logWriter
+
+	commandToRun.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if execErr := commandToRun.Start(); execErr != nil {
+		return ret, summary, fmt.Errorf("could not execute %s: %s", r.Program, execErr.Error())
+	}
+	var stopForwarding = forwardSignals(commandToRun.Process.Pid)
+	defer stopForwarding()
+
+	if r.StateDir != "" {
+		var session = r.newSession(elementNumber, targetExe, RunResult{Pid: commandToRun.Process.Pid, Log: logFile.Name()}, startTime)
+		var endSession = r.trackSession(session, nil, left, limited, r.hooks(targetExe, "LimitWarning"), nil)
+		defer endSession()
+	}
+
+	var waitErr = commandToRun.Wait()
+	var closeErr = logWriter.Close()
+
+	ret = commandResult(commandToRun, startTime)
+	ret.Pid = commandToRun.Process.Pid
+	ret.Log = logFile.Name()
+	summary = logWriter.summary
+
+	if _, isExitErr := waitErr.(*exec.ExitError); waitErr != nil && !isExitErr {
+		return ret, summary, fmt.Errorf("could not wait for %s: %s", r.Program, waitErr.Error())
+	}
+	if closeErr != nil {
+		return ret, summary, fmt.Errorf("could not write log: %s", closeErr.Error())
+	}
+
+	return
+}
+
+// check that channels are written the way WINEDEBUG has them,
+// split by commas and each starting with + or - (and maybe a class)
+func isDebugChannels(channels string) bool {
+	for _, channel := range strings.Split(channels, ",") {
+		if !debugChannelPattern.MatchString(channel) {
+			return false
+		}
+	}
+	return channels != ""
+}
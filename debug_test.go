@@ -0,0 +1,177 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugPreset(t *testing.T) {
+	var rnr = Runner{DebugPresets: map[string]string{"seh": "+seh", "heap": "+tid,+heap"}}
+
+	var testTable = []struct {
+		Description   string
+		Expected      string
+		ExpectedFound bool
+
+		ParamName string
+	}{
+		{
+			Description:   "known preset",
+			Expected:      "+loaddll",
+			ExpectedFound: true,
+			ParamName:     "loaddll",
+		},
+		{
+			Description:   "known preset set again in the config",
+			Expected:      "+seh",
+			ExpectedFound: true,
+			ParamName:     "seh",
+		},
+		{
+			Description:   "preset of the config",
+			Expected:      "+tid,+heap",
+			ExpectedFound: true,
+			ParamName:     "heap",
+		},
+		{
+			Description:   "unknown preset",
+			Expected:      "",
+			ExpectedFound: false,
+			ParamName:     "all",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenFound = rnr.debugPreset(testCase.ParamName)
+
+			if testCase.Expected != gotten || testCase.ExpectedFound != gottenFound {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+
+	var expectedNames = "[d3d file heap loaddll relay-lite seh]"
+	if gotten := fmt.Sprint(rnr.debugPresetNames()); gotten != expectedNames {
+		errorExpGot(t, expectedNames, gotten, false)
+	}
+}
+
+func TestIsDebugChannels(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    bool
+
+		ParamChannels string
+	}{
+		{
+			Description:   "channels and a class",
+			Expected:      true,
+			ParamChannels: "+tid,-heap,err+all",
+		},
+		{
+			Description:   "channel with no sign",
+			Expected:      false,
+			ParamChannels: "+tid,relay",
+		},
+		{
+			Description:   "nothing",
+			Expected:      false,
+			ParamChannels: "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = isDebugChannels(testCase.ParamChannels)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestDebugFromList(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe, writing debug output
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		List: []Exe{
+			{Name: "Script", Path: `echo "$WINEDEBUG"; ` +
+				`for i in 1 2 3; do echo "0024:trace:seh:dispatch_exception code=$i" >&2; done; ` +
+				`echo "0024:fixme:d3d:wined3d_guess_card No card found" >&2; ` +
+				`echo "0024:fixme:d3d:wined3d_guess_card No card found" >&2; exit 1`},
+		},
+	}
+
+	var gotten, gottenSummary, gottenErr = rnr.debugFromList(1, "+tid,+seh")
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+
+	var expectedSummary = "6 lines\n" +
+		"       3 trace:seh\n" +
+		"       2 fixme:d3d\n" +
+		"most frequent errors and fixmes:\n" +
+		"       2 fixme:d3d No card found\n"
+	if gottenSummary.String() != expectedSummary || gotten.code() != 1 {
+		errorExpGot(t, expectedSummary, gottenSummary.String(), false)
+	}
+
+	// the output is in the compressed log
+	var logFile, _ = os.Open(gotten.Log)
+	defer logFile.Close()
+	var reader, readerErr = gzip.NewReader(logFile)
+	if readerErr != nil {
+		errorExpGot(t, nil, readerErr, true)
+		return
+	}
+	var logData, _ = ioutil.ReadAll(reader)
+
+	if !strings.HasPrefix(string(logData), "+tid,+seh\n") || strings.Count(string(logData), "\n") != 6 {
+		errorExpGot(t, "+tid,+seh and 5 lines of debug output", string(logData), false)
+	}
+}
+
+func TestDebugFromListNextToEntry(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		List: []Exe{
+			{Name: "Script", Path: "exit 0", Single: "entry"},
+		},
+	}
+
+	// the entry runs already and is to run alone
+	var lockErr = rnr.takeLock(rnr.lockFile("entry", "exit 0"), "")
+	if lockErr != nil {
+		errorExpGot(t, nil, lockErr, true)
+		return
+	}
+
+	var _, _, gottenErr = rnr.debugFromList(1, "+seh")
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+
+	// the run of the exe is a launch of it
+	var history, _ = rnr.readHistory(time.Time{})
+	if len(history) != 1 || history[0].Name != "Script" {
+		errorExpGot(t, "a launch of Script", history, false)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// the launcher info wine puts in a .desktop file for a program
+type DesktopLauncher struct {
+	Name    string
+	Prefix  string
+	Target  string
+	WorkDir string
+	WMClass string
+}
+
+// get the dir wine puts the launchers of installed programs in
+func desktopProgramsDir() string {
+	var dataDir = os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		var homedir, _ = os.UserHomeDir()
+		dataDir = path.Join(homedir, ".local", "share")
+	}
+
+	return path.Join(dataDir, "applications", "wine", "Programs")
+}
+
+// read a .desktop file and get the wine launcher in it
+// ok is false if it does not launch something through wine
+func readDesktopFile(fileName string) (ret DesktopLauncher, ok bool, retErr error) {
+	data, retErr := ioutil.ReadFile(fileName)
+	if retErr != nil {
+		return
+	}
+
+	var inEntry bool
+	var execLine string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		// only the main group matters, not actions
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+
+		var pair = strings.SplitN(line, "=", 2)
+		if !inEntry || len(pair) != 2 {
+			continue
+		}
+
+		var value = desktopUnescape(strings.TrimSpace(pair[1]))
+		switch strings.TrimSpace(pair[0]) {
+		case "Name":
+			ret.Name = value
+		case "Exec":
+			execLine = value
+		case "Path":
+			ret.WorkDir = value
+		case "StartupWMClass":
+			ret.WMClass = value
+		}
+	}
+
+	var args = splitExec(execLine)
+
+	// skip over env and the variables given to it
+	if len(args) > 0 && args[0] == "env" {
+		args = args[1:]
+	}
+	for len(args) > 0 && strings.Contains(args[0], "=") {
+		var pair = strings.SplitN(args[0], "=", 2)
+		if pair[0] == "WINEPREFIX" {
+			ret.Prefix = pair[1]
+		}
+		args = args[1:]
+	}
+
+	// then the wine program itself
+	if len(args) < 2 || !strings.HasPrefix(path.Base(args[0]), "wine") {
+		return ret, false, nil
+	}
+	args = args[1:]
+
+	// programs might be given through start, by name or by the
+	// windows path of start.exe as the launchers of wine do
+	var startName = args[0][strings.LastIndexAny(args[0], `\/`)+1:]
+	if strings.EqualFold(args[0], "start") || strings.EqualFold(startName, "start.exe") {
+		args = args[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "/") {
+			// unix paths follow their option
+			if strings.EqualFold(args[0], "/unix") && len(args) > 1 {
+				ret.Target = args[1]
+				return ret, ret.Name != "", nil
+			}
+			args = args[1:]
+		}
+	}
+
+	if len(args) == 0 {
+		return ret, false, nil
+	}
+
+	ret.Target = args[0]
+	return ret, ret.Name != "", nil
+}
+
+// undo the escaping of values in .desktop files
+func desktopUnescape(value string) string {
+	var replacer = strings.NewReplacer(`\s`, " ", `\n`, "\n", `\t`, "\t", `\r`, "\r", `\\`, `\`)
+	return replacer.Replace(value)
+}
+
+// split the command in an Exec key into its arguments
+// taking care of quotes and backslash escapes
+func splitExec(execLine string) (retList []string) {
+	var current strings.Builder
+	var inQuotes, hasArg bool
+
+	for i := 0; i < len(execLine); i++ {
+		var char = execLine[i]
+
+		switch {
+		case char == '\\' && i+1 < len(execLine):
+			i++
+			current.WriteByte(execLine[i])
+			hasArg = true
+		case char == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case char == ' ' && !inQuotes:
+			if hasArg {
+				retList = append(retList, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteByte(char)
+			hasArg = true
+		}
+	}
+
+	if hasArg {
+		retList = append(retList, current.String())
+	}
+
+	return
+}
+
+// get the unix path of the exe a launcher runs
+func (l DesktopLauncher) exePath(defaultPrefix string) string {
+	var prefixDir = l.Prefix
+	if prefixDir == "" {
+		prefixDir = defaultPrefix
+	}
+
+	var target = l.Target
+	if isWindowsPath(target) {
+		target, _ = unixFromWindows(prefixDir, target)
+	}
+
+	// shortcuts don't say what they point at but wine tells
+	// the exe name in the window class and the dir to run in
+	if !strings.EqualFold(filepath.Ext(target), ".exe") {
+		if l.WorkDir == "" || !strings.EqualFold(filepath.Ext(l.WMClass), ".exe") {
+			return ""
+		}
+		target = path.Join(l.WorkDir, l.WMClass)
+
+		// the dir is given through the drive links so go through
+		// them again to get the same path as for windows paths
+		if winTarget, winErr := windowsFromUnix(prefixDir, target); winErr == nil {
+			target, _ = unixFromWindows(prefixDir, winTarget)
+		}
+	}
+
+	return target
+}
+
+// read the wine launchers in a dir (recursively) and get a list of exes
+// for them, each bound to the prefix it was installed to
+func importFromDesktop(dirName string, defaultPrefix string) (retList []Exe, retErr []error) {
+	var walkErr = filepath.WalkDir(dirName, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			retErr = append(retErr, err)
+			return nil
+		}
+
+		if entry.IsDir() || filepath.Ext(filePath) != ".desktop" {
+			return nil
+		}
+
+		var launcher, ok, readErr = readDesktopFile(filePath)
+		if readErr != nil {
+			retErr = append(retErr, readErr)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+
+		// leave out launchers whose exe is gone
+		var exePath = launcher.exePath(defaultPrefix)
+		if _, statErr := os.Stat(exePath); exePath == "" || statErr != nil {
+			return nil
+		}
+
+		retList = mergeExeLists(retList, []Exe{
+			{
+				Name:   launcher.Name,
+				Path:   exePath,
+				Prefix: launcher.Prefix,
+			},
+		})
+		return nil
+	})
+
+	if walkErr != nil {
+		retErr = append(retErr, walkErr)
+	}
+
+	return
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestSplitExec(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamExec string
+	}{
+		{
+			Description: "launcher of a shortcut with escaped spaces",
+			Expected: []string{
+				"env", "WINEPREFIX=/home/u/.wine", "wine",
+				`C:\ProgramData\Microsoft\Windows\Start Menu\Programs\Flap.lnk`,
+			},
+
+			ParamExec: desktopUnescape(`env WINEPREFIX="/home/u/.wine" wine C:\\\\ProgramData\\\\Microsoft\\\\Windows\\\\Start\\ Menu\\\\Programs\\\\Flap.lnk`),
+		},
+		{
+			Description: "quoted arguments and repeated spaces",
+			Expected:    []string{"wine", `C:\Games\My Game.exe`, ""},
+
+			ParamExec: `wine   "C:\\Games\\My Game.exe" ""`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = splitExec(testCase.ParamExec)
+
+			if fmt.Sprintf("%q", testCase.Expected) != fmt.Sprintf("%q", gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestReadDesktopFile(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var testFileName = inTestDir("flap.desktop")
+
+	var testTable = []struct {
+		Description string
+		Expected    DesktopLauncher
+		ExpectedOk  bool
+
+		ParamContent string
+	}{
+		{
+			Description: "launcher for an exe",
+			Expected: DesktopLauncher{
+				Name:   "Flap",
+				Prefix: "/home/u/pfx",
+				Target: `C:\Games\flap.exe`,
+			},
+			ExpectedOk: true,
+
+			ParamContent: "[Desktop Entry]\nName=Flap\n" +
+				`Exec=env WINEPREFIX="/home/u/pfx" wine C:\\\\Games\\\\flap.exe` + "\n" +
+				"[Desktop Action Other]\nName=Other\n",
+		},
+		{
+			Description: "launcher through start with a unix path",
+			Expected: DesktopLauncher{
+				Name:    "Flap",
+				Target:  "/games/flap.exe",
+				WMClass: "flap.exe",
+			},
+			ExpectedOk: true,
+
+			ParamContent: "[Desktop Entry]\nName=Flap\nStartupWMClass=flap.exe\n" +
+				"Exec=wine start /unix /games/flap.exe\n",
+		},
+		{
+			Description: "launcher made by wine through start.exe",
+			Expected: DesktopLauncher{
+				Name:    "Foo",
+				Prefix:  "/home/u/.wine",
+				Target:  "/home/u/.wine/dosdevices/c:/users/u/Desktop/Foo.lnk",
+				WMClass: "foo.exe",
+			},
+			ExpectedOk: true,
+
+			ParamContent: "[Desktop Entry]\nName=Foo\nStartupWMClass=foo.exe\n" +
+				`Exec=env WINEPREFIX="/home/u/.wine" wine C:\\\\windows\\\\command\\\\start.exe /Unix /home/u/.wine/dosdevices/c:/users/u/Desktop/Foo.lnk` + "\n",
+		},
+		{
+			Description: "launcher not going through wine",
+			Expected: DesktopLauncher{
+				Name: "Editor",
+			},
+			ExpectedOk: false,
+
+			ParamContent: "[Desktop Entry]\nName=Editor\nExec=vim %F\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.WriteFile(testFileName, []byte(testCase.ParamContent), 0644)
+			defer os.Remove(testFileName)
+
+			var gotten, gottenOk, _ = readDesktopFile(testFileName)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if testCase.ExpectedOk != gottenOk {
+				errorExpGot(t, testCase.ExpectedOk, gottenOk, false)
+			}
+		})
+	}
+}
+
+func TestImportFromDesktop(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// a prefix with a game and a dir of launchers
+	os.MkdirAll(inTestDir("pfx/drive_c/Games/Flap"), 0755)
+	os.MkdirAll(inTestDir("pfx/dosdevices"), 0755)
+	os.Symlink("../drive_c", inTestDir("pfx/dosdevices/c:"))
+	os.WriteFile(inTestDir("pfx/drive_c/Games/Flap/flap.exe"), []byte{}, 0755)
+	os.MkdirAll(inTestDir("Programs/Flap"), 0755)
+
+	var prefixDir, _ = os.Getwd()
+	prefixDir += "/" + inTestDir("pfx")
+
+	var testTable = []struct {
+		Description  string
+		Expected     []Exe
+		ExpectedErrs []error
+
+		ParamFiles map[string]string
+	}{
+		{
+			Description: "launchers of an exe and of a shortcut to it",
+			Expected: []Exe{
+				{Name: "Flap", Path: prefixDir + "/drive_c/Games/Flap/flap.exe", Prefix: prefixDir},
+			},
+			ExpectedErrs: []error{},
+
+			ParamFiles: map[string]string{
+				inTestDir("Programs/Flap/Flap.desktop"): "[Desktop Entry]\nName=Flap\n" +
+					`Exec=env WINEPREFIX="` + prefixDir + `" wine C:\\\\Games\\\\Flap\\\\flap.exe` + "\n",
+				inTestDir("Programs/Flap/Shortcut.desktop"): "[Desktop Entry]\nName=Flap Shortcut\n" +
+					`Exec=env WINEPREFIX="` + prefixDir + `" wine C:\\\\Flap.lnk` + "\n" +
+					"Path=" + prefixDir + "/dosdevices/c:/Games/Flap\nStartupWMClass=flap.exe\n",
+			},
+		},
+		{
+			Description:  "launchers of exes that are gone",
+			Expected:     []Exe{},
+			ExpectedErrs: []error{},
+
+			ParamFiles: map[string]string{
+				inTestDir("Programs/Gone.desktop"): "[Desktop Entry]\nName=Gone\n" +
+					`Exec=env WINEPREFIX="` + prefixDir + `" wine C:\\\\gone.exe` + "\n",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			for fileName, content := range testCase.ParamFiles {
+				os.WriteFile(fileName, []byte(content), 0644)
+				defer os.Remove(fileName)
+			}
+
+			var gotten, gottenErrs = importFromDesktop(inTestDir("Programs"), "")
+
+			if equalExeList(t, testCase.Expected, gotten) == false {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, testCase.ExpectedErrs, gottenErrs) == false {
+				errorExpGot(t, testCase.ExpectedErrs, gottenErrs, true)
+			}
+		})
+	}
+}
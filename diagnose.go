@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// a known failure that can be found in what wine writes, with
+// what it means ($1 and so on standing for what the pattern matched)
+type Diagnosis struct {
+	Pattern *regexp.Regexp
+	Text    string
+}
+
+// the failures known to winela, more specific ones first
+// as only the first one matching a line is taken
+var builtinDiagnoses = []Diagnosis{
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (d3dx9_\d+\.dll)`), "missing $1 - try winetricks d3dx9"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (d3dx10_\d+\.dll)`), "missing $1 - try winetricks d3dx10"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (d3dx11_\d+\.dll)`), "missing $1 - try winetricks d3dx11_43"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (d3dcompiler_\d+)\.dll`), "missing $1.dll - try winetricks $1"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library ((?:msvcp|vcruntime)140(?:_\d)?\.dll)`), "missing $1 - try winetricks vcrun2022"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (msvc[pr]120\.dll)`), "missing $1 - try winetricks vcrun2013"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (msvc[pr]110\.dll)`), "missing $1 - try winetricks vcrun2012"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (msvc[pr]100\.dll)`), "missing $1 - try winetricks vcrun2010"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (xinput1_\d\.dll)`), "missing $1 - try winetricks xinput"},
+	{regexp.MustCompile(`(?i)err:module:import_dll Library (\S+\.dll)`), "missing $1 - it may come with the program or from a winetricks verb"},
+	{regexp.MustCompile(`(?i)could not load kernel32\.dll`), "the prefix is broken (kernel32.dll could not be loaded) - try wineboot -u or making it anew"},
+	{regexp.MustCompile(`(?i)wine mono is not installed`), "Wine Mono is missing for .NET programs - install it or try winetricks dotnet48"},
+	{regexp.MustCompile(`(?i)(vkCreateInstance failed|failed to create vulkan instance|no adapters found|vulkan.*not (found|supported|available))`), "Vulkan is not working - check the vulkan drivers, 32 bit ones as well for 32 bit programs"},
+	{regexp.MustCompile(`(?i)(libGL error|GLX is not supported|direct rendering is not supported|err:wgl:)`), "OpenGL is not working - check the graphics drivers, 32 bit ones as well for 32 bit programs"},
+	{regexp.MustCompile(`(?i)wine: cannot find (.+)`), "wine can't find $1 - check the path of the entry"},
+	{regexp.MustCompile(`(?i)unhandled (exception|page fault)`), "the program crashed with an unhandled $1 - winela debug with --preset seh can tell more"},
+}
+
+// read the diagnoses written in a file as lines of a pattern (a go regular
+// expression) and what it means split by =>, a file not there has none
+func readDiagnoses(fileName string) (retList []Diagnosis, retErr error) {
+	var readData, readErr = ioutil.ReadFile(fileName)
+	if os.IsNotExist(readErr) {
+		return nil, nil
+	} else if readErr != nil {
+		return nil, readErr
+	}
+
+	for i, line := range strings.Split(string(readData), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		var pair = strings.SplitN(line, "=>", 2)
+		if len(pair) != 2 {
+			return retList, fmt.Errorf("%s: line %d: needs a pattern and a diagnosis split by =>", fileName, i+1)
+		}
+
+		var pattern, compileErr = regexp.Compile(strings.TrimSpace(pair[0]))
+		if compileErr != nil {
+			return retList, fmt.Errorf("%s: line %d: %s", fileName, i+1, compileErr.Error())
+		}
+
+		retList = append(retList, Diagnosis{Pattern: pattern, Text: strings.TrimSpace(pair[1])})
+	}
+
+	return
+}
+
+// get the diagnoses to look for, the ones of the
+// user first so they can be more specific
+func (r Runner) diagnoses() ([]Diagnosis, error) {
+	if r.DiagnosesFile == "" {
+		return builtinDiagnoses, nil
+	}
+
+	var userDiagnoses, readErr = readDiagnoses(r.DiagnosesFile)
+	return append(userDiagnoses, builtinDiagnoses...), readErr
+}
+
+// get what the failures found in lines mean, once each
+// in the order they were first found
+func diagnoseLines(lines []string, diagnoses []Diagnosis) (retList []string) {
+	var seen = make(map[string]bool)
+
+	for _, line := range lines {
+		for _, d := range diagnoses {
+			var match = d.Pattern.FindStringSubmatchIndex(line)
+			if match == nil {
+				continue
+			}
+
+			var text = string(d.Pattern.ExpandString(nil, d.Text, line, match))
+			if !seen[text] {
+				seen[text] = true
+				retList = append(retList, text)
+			}
+			break
+		}
+	}
+
+	return
+}
+
+// get what the failures found in a launch log mean
+func diagnoseLog(logPath string, diagnoses []Diagnosis) (retList []string, retErr error) {
+	var logFile, openErr = os.Open(logPath)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer logFile.Close()
+
+	var lines []string
+	var scanner = bufio.NewScanner(logFile)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return diagnoseLines(lines, diagnoses), scanner.Err()
+}
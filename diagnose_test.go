@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDiagnoseLines(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamLines []string
+	}{
+		{
+			Description: "nothing wrong",
+			Expected:    nil,
+			ParamLines:  []string{"2026-10-19T20:30:01.250 OUT: hello"},
+		},
+		{
+			Description: "missing dlls found once each",
+			Expected: []string{
+				"missing d3dx9_43.dll - try winetricks d3dx9",
+				"missing d3dcompiler_47.dll - try winetricks d3dcompiler_47",
+				"missing steam_api.dll - it may come with the program or from a winetricks verb",
+			},
+			ParamLines: []string{
+				`ERR: 0024:err:module:import_dll Library d3dx9_43.dll (which is needed by L"C:\\game.exe") not found`,
+				`ERR: 0024:err:module:import_dll Library d3dx9_43.dll (which is needed by L"C:\\other.dll") not found`,
+				`ERR: 0024:err:module:import_dll Library d3dcompiler_47.dll (which is needed by L"C:\\game.exe") not found`,
+				`ERR: 0024:err:module:import_dll Library steam_api.dll (which is needed by L"C:\\game.exe") not found`,
+			},
+		},
+		{
+			Description: "missing dll needed by a path with spaces and dlls in it",
+			Expected: []string{
+				"missing MFC42.DLL - it may come with the program or from a winetricks verb",
+				"missing msvcp140.dll - try winetricks vcrun2022",
+			},
+			ParamLines: []string{
+				`ERR: 0024:err:module:import_dll Library MFC42.DLL (which is needed by L"C:\\Program Files\\App\\plugin.dll") not found`,
+				`ERR: 0024:err:module:import_dll Library msvcp140.dll (which is needed by L"C:\\Program Files\\App\\d3dx9_43.dll") not found`,
+			},
+		},
+		{
+			Description: "broken prefix and a crash",
+			Expected: []string{
+				"the prefix is broken (kernel32.dll could not be loaded) - try wineboot -u or making it anew",
+				"the program crashed with an unhandled page fault - winela debug with --preset seh can tell more",
+			},
+			ParamLines: []string{
+				"ERR: wine: could not load kernel32.dll, status c0000135",
+				"ERR: wine: Unhandled page fault on read access to 00000000 at address 0040100A",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = diagnoseLines(testCase.ParamLines, builtinDiagnoses)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestRunnerDiagnoses(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var testTable = []struct {
+		Description string
+		Expected    []string
+		ExpectedErr error
+
+		ParamContent string
+	}{
+		{
+			Description: "diagnosis of the user over the known ones",
+			Expected:    []string{"the anti cheat refuses wine"},
+			ExpectedErr: nil,
+
+			ParamContent: "# known to us\n\nEasyAntiCheat|unhandled exception => the anti cheat refuses wine\n",
+		},
+		{
+			Description: "broken pattern",
+			Expected:    []string{"the program crashed with an unhandled exception - winela debug with --preset seh can tell more"},
+			ExpectedErr: fmt.Errorf("%s: line 1: error parsing regexp: missing closing ): `(EasyAntiCheat`", inTestDir("diagnoses")),
+
+			ParamContent: "(EasyAntiCheat => the anti cheat refuses wine\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var rnr = Runner{DiagnosesFile: inTestDir("diagnoses")}
+			ioutil.WriteFile(rnr.DiagnosesFile, []byte(testCase.ParamContent), 0644)
+
+			var diagnoses, gottenErr = rnr.diagnoses()
+			var gotten = diagnoseLines([]string{"ERR: EasyAntiCheat: unhandled exception"}, diagnoses)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the order wine tries the native and builtin versions of a dll in,
+// an empty order disables the dll
+type DllOverride struct {
+	Dll   string
+	Order string
+}
+
+// the orders a dll can be loaded in and the words they can be given by
+var dllOrders = map[string]string{
+	"n":              "n",
+	"native":         "n",
+	"b":              "b",
+	"builtin":        "b",
+	"n,b":            "n,b",
+	"native,builtin": "n,b",
+	"b,n":            "b,n",
+	"builtin,native": "b,n",
+	"d":              "",
+	"disabled":       "",
+}
+
+// read a dll override as written in the list or settings files,
+// the dll followed by its order (native, builtin, disabled or both
+// of the first two split by a comma in the order they are tried)
+func parseDllOverride(value string) (ret DllOverride, retErr error) {
+	var fields = strings.Fields(value)
+	if len(fields) != 2 {
+		return ret, fmt.Errorf("dll %q: needs a dll and an order", value)
+	}
+
+	ret.Dll = strings.TrimSuffix(strings.ToLower(fields[0]), ".dll")
+	if ret.Dll == "" || strings.ContainsAny(ret.Dll, "=;,/\\") {
+		return ret, fmt.Errorf("dll %q: %s is not a dll name", value, fields[0])
+	}
+
+	var order, known = dllOrders[strings.ToLower(fields[1])]
+	if !known {
+		return ret, fmt.Errorf("dll %q: %s is not native, builtin or disabled", value, fields[1])
+	}
+	ret.Order = order
+
+	return
+}
+
+// write a dll override the way it is read
+func (o DllOverride) String() string {
+	if o.Order == "" {
+		return o.Dll + " disabled"
+	}
+	return o.Dll + " " + o.Order
+}
+
+// put dll overrides over each other, later ones replacing
+// earlier ones of the same dll, sorted by dll
+func mergeDllOverrides(lists ...[]DllOverride) (retList []DllOverride) {
+	for _, list := range lists {
+		for _, override := range list {
+			var replaced bool
+			for i := range retList {
+				if retList[i].Dll == override.Dll {
+					retList[i] = override
+					replaced = true
+				}
+			}
+			if !replaced {
+				retList = append(retList, override)
+			}
+		}
+	}
+
+	sort.Slice(retList, func(i, j int) bool {
+		return retList[i].Dll < retList[j].Dll
+	})
+
+	return
+}
+
+// replace the override of a dll with another or add it
+func setDllOverride(list []DllOverride, override DllOverride) []DllOverride {
+	return mergeDllOverrides(list, []DllOverride{override})
+}
+
+// remove the override of a dll
+func unsetDllOverride(list []DllOverride, dll string) (retList []DllOverride) {
+	var name = strings.TrimSuffix(strings.ToLower(dll), ".dll")
+	for _, override := range list {
+		if override.Dll != name {
+			retList = append(retList, override)
+		}
+	}
+	return
+}
+
+// write dll overrides the way wine reads them from WINEDLLOVERRIDES
+func dllOverridesEnv(list []DllOverride) string {
+	var parts []string
+	for _, override := range list {
+		parts = append(parts, override.Dll+"="+override.Order)
+	}
+	return strings.Join(parts, ";")
+}
+
+// return the dll overrides of the program, a prefix and an entry
+// as lines of text, noting where each one comes from
+func displayDllOverrides(global []DllOverride, prefix []DllOverride, entry []DllOverride) (ret string) {
+	var sources = make(map[string]string)
+	for _, override := range global {
+		sources[override.Dll] = "winelarc"
+	}
+	for _, override := range prefix {
+		sources[override.Dll] = "prefix"
+	}
+	for _, override := range entry {
+		sources[override.Dll] = "entry"
+	}
+
+	for _, override := range mergeDllOverrides(global, prefix, entry) {
+		ret += fmt.Sprintf("%s (%s)\n", override.String(), sources[override.Dll])
+	}
+
+	return
+}
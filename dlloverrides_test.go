@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseDllOverride(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    DllOverride
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "short order",
+			Expected:    DllOverride{Dll: "d3d9", Order: "n,b"},
+			ExpectedErr: nil,
+			ParamValue:  "d3d9 n,b",
+		},
+		{
+			Description: "long order and a dll with its extension",
+			Expected:    DllOverride{Dll: "dxgi", Order: "n"},
+			ExpectedErr: nil,
+			ParamValue:  "DXGI.dll Native",
+		},
+		{
+			Description: "disabled dll",
+			Expected:    DllOverride{Dll: "winemenubuilder.exe", Order: ""},
+			ExpectedErr: nil,
+			ParamValue:  "winemenubuilder.exe disabled",
+		},
+		{
+			Description: "no order",
+			Expected:    DllOverride{},
+			ExpectedErr: fmt.Errorf(`dll "d3d9": needs a dll and an order`),
+			ParamValue:  "d3d9",
+		},
+		{
+			Description: "misspelled order",
+			Expected:    DllOverride{Dll: "d3d9"},
+			ExpectedErr: fmt.Errorf(`dll "d3d9 nativ": nativ is not native, builtin or disabled`),
+			ParamValue:  "d3d9 nativ",
+		},
+		{
+			Description: "name with a separator",
+			Expected:    DllOverride{Dll: "d3d9=n"},
+			ExpectedErr: fmt.Errorf(`dll "d3d9=n b": d3d9=n is not a dll name`),
+			ParamValue:  "d3d9=n b",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseDllOverride(testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestMergeDllOverrides(t *testing.T) {
+	var global = []DllOverride{{Dll: "dxgi", Order: "b"}, {Dll: "d3d9", Order: "b"}}
+	var prefix = []DllOverride{{Dll: "d3d11", Order: "n"}}
+	var entry = []DllOverride{{Dll: "dxgi", Order: "n,b"}, {Dll: "mscoree", Order: ""}}
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamLists [][]DllOverride
+	}{
+		{
+			Description: "nothing to merge",
+			Expected:    "",
+			ParamLists:  nil,
+		},
+		{
+			Description: "entry over prefix over program",
+			Expected:    "d3d11=n;d3d9=b;dxgi=n,b;mscoree=",
+			ParamLists:  [][]DllOverride{global, prefix, entry},
+		},
+		{
+			Description: "unset from the program",
+			Expected:    "dxgi=b",
+			ParamLists:  [][]DllOverride{unsetDllOverride(global, "D3D9.dll")},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = dllOverridesEnv(mergeDllOverrides(testCase.ParamLists...))
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestDisplayDllOverrides(t *testing.T) {
+	var global = []DllOverride{{Dll: "dxgi", Order: "b"}, {Dll: "d3d9", Order: "b"}}
+	var prefix = []DllOverride{{Dll: "d3d9", Order: "n"}}
+	var entry = []DllOverride{{Dll: "mscoree", Order: ""}}
+
+	var expected = "d3d9 n (prefix)\ndxgi b (winelarc)\nmscoree disabled (entry)\n"
+	var gotten = displayDllOverrides(global, prefix, entry)
+
+	if expected != gotten {
+		errorExpGot(t, expected, gotten, false)
+	}
+}
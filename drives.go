@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// a drive letter mapped to a dir on the host
+type DriveMap struct {
+	Letter string
+	Target string
+}
+
+// read a drive mapping as written in the list or settings
+// files, the letter followed by the dir it maps to
+func parseDriveMap(value string) (ret DriveMap, retErr error) {
+	var fields = strings.SplitN(strings.TrimSpace(value), " ", 2)
+	if len(fields) != 2 {
+		return ret, fmt.Errorf("drive %q: needs a letter and a dir", value)
+	}
+
+	ret.Letter = strings.ToLower(fields[0])
+	ret.Target = strings.TrimSpace(fields[1])
+
+	if !isWindowsPath(ret.Letter) || len(ret.Letter) != 2 {
+		return ret, fmt.Errorf("drive %q: %s is not a drive letter", value, fields[0])
+	}
+
+	// relative links would be relative to the dosdevices dir
+	if !path.IsAbs(ret.Target) {
+		return ret, fmt.Errorf("drive %q: %s is not an absolute path", value, ret.Target)
+	}
+
+	// the system drive is the prefix itself
+	if ret.Letter == "c:" {
+		return ret, fmt.Errorf("drive %q: c: can't be remapped", value)
+	}
+
+	return
+}
+
+// write a drive mapping the way it is read
+func (d DriveMap) String() string {
+	return d.Letter + " " + d.Target
+}
+
+// put drive mappings over each other, later
+// ones replacing earlier ones with the same letter
+func mergeDriveMaps(lists ...[]DriveMap) (retList []DriveMap) {
+	for _, list := range lists {
+		for _, drive := range list {
+			var replaced bool
+			for i := range retList {
+				if retList[i].Letter == drive.Letter {
+					retList[i] = drive
+					replaced = true
+				}
+			}
+			if !replaced {
+				retList = append(retList, drive)
+			}
+		}
+	}
+
+	return
+}
+
+// replace a drive mapping with another or add it
+func setDriveMap(list []DriveMap, drive DriveMap) []DriveMap {
+	return mergeDriveMaps(list, []DriveMap{drive})
+}
+
+// remove the mapping of a drive letter
+func unsetDriveMap(list []DriveMap, letter string) (retList []DriveMap) {
+	for _, drive := range list {
+		if drive.Letter != strings.ToLower(letter) {
+			retList = append(retList, drive)
+		}
+	}
+	return
+}
+
+// link drive letters of a prefix to the dirs they are mapped to,
+// returning what they linked to before ("" for no link) to restore them
+func applyDrives(prefixDir string, drives []DriveMap) (previous map[string]string, retErr error) {
+	// check all targets before changing anything
+	for _, drive := range drives {
+		if _, statErr := os.Stat(drive.Target); statErr != nil {
+			return nil, fmt.Errorf("drive %s %s", drive.Letter, statErr.Error())
+		}
+	}
+
+	var devicesDir = path.Join(prefixDir, "dosdevices")
+	previous = make(map[string]string)
+
+	for _, drive := range drives {
+		var linkPath = path.Join(devicesDir, drive.Letter)
+
+		// remember the current link and remove it
+		var oldTarget, _ = os.Readlink(linkPath)
+		previous[drive.Letter] = oldTarget
+
+		if oldTarget == drive.Target {
+			continue
+		}
+
+		if removeErr := os.Remove(linkPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return previous, fmt.Errorf("drive %s %s", drive.Letter, removeErr.Error())
+		}
+
+		if linkErr := os.Symlink(drive.Target, linkPath); linkErr != nil {
+			return previous, fmt.Errorf("drive %s %s", drive.Letter, linkErr.Error())
+		}
+	}
+
+	return
+}
+
+// put back the drive links of a prefix as they were before applying
+func restoreDrives(prefixDir string, previous map[string]string) (retErr error) {
+	var devicesDir = path.Join(prefixDir, "dosdevices")
+
+	for letter, oldTarget := range previous {
+		var linkPath = path.Join(devicesDir, letter)
+
+		if currentTarget, _ := os.Readlink(linkPath); currentTarget == oldTarget {
+			continue
+		}
+
+		if removeErr := os.Remove(linkPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			retErr = removeErr
+			continue
+		}
+
+		// drives that were not there before stay removed
+		if oldTarget == "" {
+			continue
+		}
+
+		if linkErr := os.Symlink(oldTarget, linkPath); linkErr != nil {
+			retErr = linkErr
+		}
+	}
+
+	return
+}
+
+// return the current drive mappings of a prefix as lines of text,
+// noting the declared mappings that will replace them on launch
+func displayDrives(prefixDir string, declared []DriveMap) (ret string, retErr error) {
+	var current, readErr = readDosDevices(prefixDir)
+	if readErr != nil {
+		return "", readErr
+	}
+
+	// show mappings not yet applied as well
+	var letters []string
+	for letter := range current {
+		letters = append(letters, letter)
+	}
+	for _, drive := range declared {
+		if _, found := current[drive.Letter]; !found {
+			letters = append(letters, drive.Letter)
+		}
+	}
+	sort.Strings(letters)
+
+	for _, letter := range letters {
+		var line = fmt.Sprintf("%s %s", letter, current[letter])
+		if current[letter] == "" {
+			line = fmt.Sprintf("%s -", letter)
+		}
+
+		for _, drive := range declared {
+			if drive.Letter == letter && path.Clean(drive.Target) != current[letter] {
+				line += fmt.Sprintf(" (mapped to %s on launch)", drive.Target)
+			}
+		}
+
+		ret += line + "\n"
+	}
+
+	return
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestParseDriveMap(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    DriveMap
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "a letter and a dir with spaces",
+			Expected:    DriveMap{Letter: "d:", Target: "/mnt/game cd"},
+			ExpectedErr: nil,
+			ParamValue:  "D: /mnt/game cd",
+		},
+		{
+			Description: "no dir",
+			Expected:    DriveMap{},
+			ExpectedErr: fmt.Errorf(`drive "d:": needs a letter and a dir`),
+			ParamValue:  "d:",
+		},
+		{
+			Description: "not a letter",
+			Expected:    DriveMap{Letter: "dd:", Target: "/mnt"},
+			ExpectedErr: fmt.Errorf(`drive "dd: /mnt": dd: is not a drive letter`),
+			ParamValue:  "dd: /mnt",
+		},
+		{
+			Description: "relative dir",
+			Expected:    DriveMap{Letter: "g:", Target: "games"},
+			ExpectedErr: fmt.Errorf(`drive "g: games": games is not an absolute path`),
+			ParamValue:  "g: games",
+		},
+		{
+			Description: "the system drive",
+			Expected:    DriveMap{Letter: "c:", Target: "/mnt"},
+			ExpectedErr: fmt.Errorf(`drive "c: /mnt": c: can't be remapped`),
+			ParamValue:  "c: /mnt",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseDriveMap(testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestMergeDriveMaps(t *testing.T) {
+	var gotten = mergeDriveMaps(
+		[]DriveMap{{Letter: "d:", Target: "/mnt/cd"}, {Letter: "g:", Target: "/games"}},
+		[]DriveMap{{Letter: "d:", Target: "/isos/game"}, {Letter: "e:", Target: "/mnt/e"}},
+	)
+	var expected = "[d: /isos/game g: /games e: /mnt/e]"
+
+	if fmt.Sprint(gotten) != expected {
+		errorExpGot(t, expected, gotten, false)
+	}
+}
+
+func TestApplyRestoreDrives(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var cwd, _ = os.Getwd()
+	var prefixDir = inTestDir("prefix")
+	os.MkdirAll(inTestDir("prefix/dosdevices"), 0755)
+	os.MkdirAll(inTestDir("cd"), 0755)
+	os.Symlink("/mnt/old", inTestDir("prefix/dosdevices/d:"))
+
+	var testTable = []struct {
+		Description      string
+		ExpectedApplied  string
+		ExpectedRestored string
+		ExpectedErr      error
+
+		ParamDrives []DriveMap
+	}{
+		{
+			Description:      "map an existing and a new drive",
+			ExpectedApplied:  fmt.Sprintf("map[d::%[1]s/%[2]s g::%[1]s/%[2]s]", cwd, inTestDir("cd")),
+			ExpectedRestored: "map[d::/mnt/old]",
+			ExpectedErr:      nil,
+
+			ParamDrives: []DriveMap{
+				{Letter: "d:", Target: cwd + "/" + inTestDir("cd")},
+				{Letter: "g:", Target: cwd + "/" + inTestDir("cd")},
+			},
+		},
+		{
+			Description:      "map to a dir that does not exist",
+			ExpectedApplied:  "map[d::/mnt/old]",
+			ExpectedRestored: "map[d::/mnt/old]",
+			ExpectedErr:      fmt.Errorf("drive g: stat /missing: no such file or directory"),
+
+			ParamDrives: []DriveMap{
+				{Letter: "d:", Target: cwd + "/" + inTestDir("cd")},
+				{Letter: "g:", Target: "/missing"},
+			},
+		},
+	}
+
+	// read the links as they are without resolving
+	var readLinks = func() map[string]string {
+		var ret = make(map[string]string)
+		for _, letter := range []string{"d:", "g:"} {
+			if target, linkErr := os.Readlink(inTestDir("prefix/dosdevices/" + letter)); linkErr == nil {
+				ret[letter] = target
+			}
+		}
+		return ret
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var previous, gottenErr = applyDrives(prefixDir, testCase.ParamDrives)
+
+			if fmt.Sprint(readLinks()) != testCase.ExpectedApplied {
+				errorExpGot(t, testCase.ExpectedApplied, readLinks(), false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+
+			restoreDrives(prefixDir, previous)
+
+			if fmt.Sprint(readLinks()) != testCase.ExpectedRestored {
+				errorExpGot(t, testCase.ExpectedRestored, readLinks(), false)
+			}
+		})
+	}
+}
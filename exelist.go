@@ -6,12 +6,156 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 type Exe struct {
 	Name string
 	Path string
+
+	// optional attributes stored under the entry in the list file
+	Prefix   string
+	Icon     string
+	Windows  string
+	Desktop  string
+	Wait     string
+	Limit    string
+	Quota    string
+	Single   string
+	Hooks    []Hook
+	Saves    []string
+	Verify   string
+	Sha256   string
+	Size     int64
+	Drives   []DriveMap
+	Dlls     []DllOverride
+	Registry []RegTweak
+
+	// attribute lines that could not be used, kept as they
+	// are to be written back to the list file
+	Invalid []string
+}
+
+// set an attribute of the exe by the name it has in the list file,
+// returning an error for a value that can't be used
+func (e *Exe) setAttribute(key string, value string) error {
+	var valid = true
+
+	switch key {
+	case "Prefix":
+		e.Prefix = value
+	case "Icon":
+		e.Icon = value
+	case "Windows":
+		if valid = isWindowsVersion(value); valid {
+			e.Windows = strings.ToLower(value)
+		}
+	case "Desktop":
+		if valid = isDesktopSize(value); valid {
+			e.Desktop = value
+		}
+	case "Wait":
+		if valid = isWaitStrategy(value); valid {
+			e.Wait = value
+		}
+	case "Limit":
+		if valid = isLimit(value); valid {
+			e.Limit = value
+		}
+	case "Quota":
+		if valid = isLimit(value); valid {
+			e.Quota = value
+		}
+	case "Single":
+		if valid = isSinglePolicy(value); valid {
+			e.Single = value
+		}
+	case "PreLaunch", "PostLaunch", "PostExit", "LimitWarning":
+		e.Hooks = append(e.Hooks, Hook{When: key, Command: value})
+	case "Save":
+		if valid = value != ""; valid {
+			e.Saves = append(e.Saves, value)
+		}
+	case "Verify":
+		if valid = isVerifyPolicy(value); valid {
+			e.Verify = value
+		}
+	case "Sha256":
+		e.Sha256 = strings.ToLower(value)
+	case "Size":
+		e.Size, _ = strconv.ParseInt(value, 10, 64)
+	case "Drive":
+		var drive, parseErr = parseDriveMap(value)
+		if parseErr != nil {
+			return parseErr
+		}
+		e.Drives = append(e.Drives, drive)
+	case "Dll":
+		var override, parseErr = parseDllOverride(value)
+		if parseErr != nil {
+			return parseErr
+		}
+		e.Dlls = setDllOverride(e.Dlls, override)
+	case "Registry":
+		var tweak, parseErr = parseRegTweak(value)
+		if parseErr != nil {
+			return parseErr
+		}
+		e.Registry = append(e.Registry, tweak)
+	}
+
+	if !valid {
+		return fmt.Errorf("%s %q can't be used", key, value)
+	}
+	return nil
+}
+
+// return the attributes of the exe that are set as indented lines
+// the way they are stored under the entry in the list file
+func (e Exe) attributeLines() (ret string) {
+	// the size is only known along with the checksum
+	var size string
+	if e.Sha256 != "" {
+		size = strconv.FormatInt(e.Size, 10)
+	}
+
+	var leftList = []string{
+		"Prefix", "Icon", "Windows", "Desktop", "Wait", "Limit", "Quota", "Single", "Verify", "Sha256", "Size",
+	}
+	var rightList = []string{
+		e.Prefix, e.Icon, e.Windows, e.Desktop, e.Wait, e.Limit, e.Quota, e.Single, e.Verify, e.Sha256, size,
+	}
+
+	for i := range leftList {
+		// leave out attributes that are not set
+		if rightList[i] == "" {
+			continue
+		}
+		ret += fmt.Sprintf("\t%s = %s\n", leftList[i], rightList[i])
+	}
+
+	// attributes that can be given more than once
+	for _, drive := range e.Drives {
+		ret += fmt.Sprintf("\tDrive = %s\n", drive.String())
+	}
+	for _, override := range e.Dlls {
+		ret += fmt.Sprintf("\tDll = %s\n", override.String())
+	}
+	for _, tweak := range e.Registry {
+		ret += fmt.Sprintf("\tRegistry = %s\n", tweak.String())
+	}
+	for _, save := range e.Saves {
+		ret += fmt.Sprintf("\tSave = %s\n", save)
+	}
+	for _, hook := range e.Hooks {
+		ret += fmt.Sprintf("\t%s = %s\n", hook.When, hook.Command)
+	}
+	for _, line := range e.Invalid {
+		ret += fmt.Sprintf("\t%s\n", line)
+	}
+
+	return
 }
 
 // read a file with a specific (exelist) format and get the list in it
@@ -29,6 +173,29 @@ func importFromFile(fileName string) (retList []Exe, retErr error) {
 	// loop through the strings
 	for _, entryFull := range entriesInFile {
 
+		// indented lines without a separator are attributes
+		// of the entry before them
+		var isIndented = strings.HasPrefix(entryFull, "\t") || strings.HasPrefix(entryFull, " ")
+		if isIndented && !strings.Contains(entryFull, "=>") {
+			var pair = strings.SplitN(entryFull, "=", 2)
+			if len(pair) != 2 || len(retList) == 0 {
+				continue
+			}
+
+			var setErr = retList[len(retList)-1].setAttribute(
+				strings.TrimSpace(pair[0]),
+				strings.TrimSpace(pair[1]),
+			)
+
+			// keep what can't be used to not lose it on the next
+			// write of the list, and tell about it
+			if setErr != nil {
+				retList[len(retList)-1].Invalid = append(retList[len(retList)-1].Invalid, strings.TrimSpace(entryFull))
+				fmt.Fprintf(os.Stderr, "winela: warning: %s: %s: %s\n", fileName, retList[len(retList)-1].Name, setErr.Error())
+			}
+			continue
+		}
+
 		// split each line into two parts
 		var entryInTwo = strings.Split(entryFull, "=>")
 
@@ -136,6 +303,7 @@ func exportToFile(fileName string, listToWrite []Exe) (retErr error) {
 	var dataAsString string
 	for _, element := range listToWrite {
 		dataAsString += fmt.Sprintf("%s => %s\n", element.Name, element.Path)
+		dataAsString += element.attributeLines()
 	}
 
 	// write the acquired string to the specidied file
@@ -149,3 +317,42 @@ func exportToFile(fileName string, listToWrite []Exe) (retErr error) {
 
 	return
 }
+
+// put back the entries already known for the paths of a scanned
+// list, so a scan does not drop their names and attributes
+func keepKnownExes(list []Exe, known []Exe) []Exe {
+	var entries = make(map[string]Exe)
+	for _, element := range known {
+		entries[element.Path] = element
+	}
+
+	for i := range list {
+		if before, found := entries[list[i].Path]; found {
+			list[i] = before
+		}
+	}
+
+	return list
+}
+
+// add the exes of one list to another leaving out
+// the ones whose path is already in the list
+func mergeExeLists(listA []Exe, listB []Exe) (retList []Exe) {
+	retList = append(retList, listA...)
+
+	// paths already in the list
+	var havePath = make(map[string]bool)
+	for _, element := range listA {
+		havePath[element.Path] = true
+	}
+
+	for _, element := range listB {
+		if havePath[element.Path] {
+			continue
+		}
+		havePath[element.Path] = true
+		retList = append(retList, element)
+	}
+
+	return
+}
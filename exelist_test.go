@@ -28,9 +28,9 @@ func TestExportImport(t *testing.T) {
 		{
 			Description: "scan a dir and export result to a file then import back from exported file",
 			Expected: []Exe{
-				{"flap", inTestDir("games/flap.exe")},
-				{"paint", inTestDir("ms/paint.exe")},
-				{"pt", inTestDir("pt.exe")},
+				{Name: "flap", Path: inTestDir("games/flap.exe")},
+				{Name: "paint", Path: inTestDir("ms/paint.exe")},
+				{Name: "pt", Path: inTestDir("pt.exe")},
 			},
 			ExpectedErrs: []error{},
 
@@ -120,7 +120,7 @@ func TestImportFromFile(t *testing.T) {
 	}{
 		{
 			Description: "import regular file",
-			Expected:    []Exe{{"okay", "~/Downloads/okay.exe"}},
+			Expected:    []Exe{{Name: "okay", Path: "~/Downloads/okay.exe"}},
 			ExpectedErr: nil,
 
 			ParamContent: "okay => ~/Downloads/okay.exe\n",
@@ -137,14 +137,30 @@ func TestImportFromFile(t *testing.T) {
 		{
 			Description: "multiple separators in one line in file",
 			Expected: []Exe{
-				{"okay", "~/Downloads/okay.exe"},
-				{"yes", "~/go/bin/yes.exe"},
+				{Name: "okay", Path: "~/Downloads/okay.exe"},
+				{Name: "yes", Path: "~/go/bin/yes.exe"},
 			},
 			ExpectedErr: nil,
 
 			ParamContent: "okay => ~/Downloads/okay.exe\n" + "hey=>~/hey.exe=>exe\n" + "yes=> ~/go/bin/yes.exe\n",
 			ParamFile:    PairPathPerm{Path: testFileName, Perm: 0755},
 		},
+		{
+			Description: "entries with attributes under them",
+			Expected: []Exe{
+				{
+					Name: "okay", Path: "~/Downloads/okay.exe", Prefix: "games", Icon: "~/okay.ico",
+					Drives: []DriveMap{{Letter: "d:", Target: "/mnt/cd"}}, Invalid: []string{"Drive = c: /mnt/cd"},
+				},
+				{Name: "yes", Path: "~/go/bin/yes.exe", Invalid: []string{"Dll = d3d9", "Limit = soon"}},
+			},
+			ExpectedErr: nil,
+
+			ParamContent: "\tPrefix = lost\n" + "okay => ~/Downloads/okay.exe\n" + "\tPrefix = games\n" +
+				"    Icon = ~/okay.ico\n" + "\tUnknown = value\n" + "\tDrive = D: /mnt/cd\n" +
+				"\tDrive = c: /mnt/cd\n" + "yes=> ~/go/bin/yes.exe\n" + "\tDll = d3d9\n" + "\tLimit = soon\n",
+			ParamFile: PairPathPerm{Path: testFileName, Perm: 0755},
+		},
 	}
 
 	// case cycling
@@ -351,8 +367,22 @@ func TestExportToFile(t *testing.T) {
 				Perm: 0755,
 			},
 			ParamList: []Exe{
-				{"ck", "~/Games/ck/ck.exe"},
-				{"fff", "~/Downloads/fff.exe"},
+				{Name: "ck", Path: "~/Games/ck/ck.exe"},
+				{Name: "fff", Path: "~/Downloads/fff.exe"},
+			},
+		},
+		{
+			Description: "exporting entries with attributes",
+			Expected:    "ck => ~/Games/ck/ck.exe\n\tPrefix = games\n\tIcon = ~/ck.ico\nfff => ~/Downloads/fff.exe\n",
+			ExpectedErr: nil,
+
+			ParamFile: PairPathPerm{
+				Path: inTestDir("exportedFile"),
+				Perm: 0755,
+			},
+			ParamList: []Exe{
+				{Name: "ck", Path: "~/Games/ck/ck.exe", Prefix: "games", Icon: "~/ck.ico"},
+				{Name: "fff", Path: "~/Downloads/fff.exe"},
 			},
 		},
 		{
@@ -365,8 +395,8 @@ func TestExportToFile(t *testing.T) {
 				Perm: 0755,
 			},
 			ParamList: []Exe{
-				{"ck", "~/Games/ck/ck.exe"},
-				{"fff", "~/Downloads/fff.exe"},
+				{Name: "ck", Path: "~/Games/ck/ck.exe"},
+				{Name: "fff", Path: "~/Downloads/fff.exe"},
 			},
 		},
 	}
@@ -398,3 +428,101 @@ func TestExportToFile(t *testing.T) {
 		})
 	}
 }
+
+func TestKeepKnownExes(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []Exe
+
+		ParamList  []Exe
+		ParamKnown []Exe
+	}{
+		{
+			Description: "known path keeps its entry",
+			Expected: []Exe{
+				{Name: "Game", Path: "~/ck.exe", Prefix: "games", Limit: "2h", Dlls: []DllOverride{{Dll: "d3d9", Order: "n,b"}}},
+				{Name: "new", Path: "~/new.exe"},
+			},
+
+			ParamList: []Exe{
+				{Name: "ck", Path: "~/ck.exe"},
+				{Name: "new", Path: "~/new.exe"},
+			},
+			ParamKnown: []Exe{
+				{Name: "Game", Path: "~/ck.exe", Prefix: "games", Limit: "2h", Dlls: []DllOverride{{Dll: "d3d9", Order: "n,b"}}},
+				{Name: "gone", Path: "~/gone.exe"},
+			},
+		},
+		{
+			Description: "nothing known",
+			Expected: []Exe{
+				{Name: "new", Path: "~/new.exe"},
+			},
+
+			ParamList: []Exe{
+				{Name: "new", Path: "~/new.exe"},
+			},
+			ParamKnown: []Exe{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = keepKnownExes(testCase.ParamList, testCase.ParamKnown)
+
+			if equalExeList(t, testCase.Expected, gotten) == false {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestMergeExeLists(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []Exe
+
+		ParamListA []Exe
+		ParamListB []Exe
+	}{
+		{
+			Description: "merge lists with a path in both",
+			Expected: []Exe{
+				{Name: "ck", Path: "~/ck.exe"},
+				{Name: "fff", Path: "~/fff.exe"},
+				{Name: "new", Path: "~/new.exe", Prefix: "games"},
+			},
+
+			ParamListA: []Exe{
+				{Name: "ck", Path: "~/ck.exe"},
+				{Name: "fff", Path: "~/fff.exe"},
+			},
+			ParamListB: []Exe{
+				{Name: "other ck", Path: "~/ck.exe", Prefix: "games"},
+				{Name: "new", Path: "~/new.exe", Prefix: "games"},
+			},
+		},
+		{
+			Description: "merge into an empty list",
+			Expected: []Exe{
+				{Name: "new", Path: "~/new.exe"},
+			},
+
+			ParamListA: []Exe{},
+			ParamListB: []Exe{
+				{Name: "new", Path: "~/new.exe"},
+				{Name: "new again", Path: "~/new.exe"},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = mergeExeLists(testCase.ParamListA, testCase.ParamListB)
+
+			if equalExeList(t, testCase.Expected, gotten) == false {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
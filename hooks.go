@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// a command run around a launch, before it starts (PreLaunch),
+// once it started (PostLaunch), after it ended (PostExit) or when
+// its time is about to run out (LimitWarning)
+type Hook struct {
+	When    string
+	Command string
+}
+
+// get the commands to run at a time around the launch
+// of an exe, the ones of winelarc before its own
+func (r Runner) hooks(targetExe Exe, when string) (retList []string) {
+	for _, hook := range append(append([]Hook{}, r.Hooks...), targetExe.Hooks...) {
+		if hook.When == when {
+			retList = append(retList, hook.Command)
+		}
+	}
+	return
+}
+
+// get the hooks run by the winela writing the log of a detached
+// exe, the ones warning of its time running out and after it exits
+func (r Runner) logWriterHooks(targetExe Exe) (retList []Hook) {
+	for _, when := range []string{"LimitWarning", "PostExit"} {
+		for _, command := range r.hooks(targetExe, when) {
+			retList = append(retList, Hook{When: when, Command: command})
+		}
+	}
+	return
+}
+
+// get the environment telling hooks about the launch they are run
+// around, the pid once it started and how it exited once known
+func hookEnv(when string, s Session, exitCode string) (retList []string) {
+	retList = []string{
+		"WINELA_HOOK=" + when,
+		"WINELA_ENTRY=" + strconv.Itoa(s.Entry),
+		"WINELA_NAME=" + s.Name,
+		"WINELA_PATH=" + s.Path,
+		"WINELA_PREFIX=" + s.Prefix,
+		"WINELA_LOG=" + s.Log,
+	}
+	if s.Pid != 0 {
+		retList = append(retList, "WINELA_PID="+strconv.Itoa(s.Pid))
+	}
+	if exitCode != "" {
+		retList = append(retList, "WINELA_EXIT="+exitCode)
+	}
+	return
+}
+
+// run hook commands one after another with the shell, their output
+// going to the log and the terminal if given, stopping at the first
+// that fails, which is noted in the log as well
+func runHooks(commands []string, env []string, logFile io.Writer, terminal io.Writer) error {
+	for _, command := range commands {
+		var hookErr = runHook(command, env, logFile, terminal)
+		if hookErr != nil {
+			if logFile != nil {
+				io.WriteString(logFile, logLine("HOOK:", hookErr.Error(), time.Now()))
+			}
+			return hookErr
+		}
+	}
+	return nil
+}
+
+// run a hook command with both of its streams going to the log
+func runHook(command string, env []string, logFile io.Writer, terminal io.Writer) error {
+	var outReader, outWriter, pipeErr = os.Pipe()
+	if pipeErr != nil {
+		return pipeErr
+	}
+	defer outReader.Close()
+
+	var hook = exec.Command("sh", "-c", command)
+	hook.Env = append(os.Environ(), env...)
+	hook.Stdout = outWriter
+	hook.Stderr = outWriter
+
+	var startErr = hook.Start()
+	outWriter.Close()
+	if startErr != nil {
+		return fmt.Errorf("hook %q: %s", command, startErr.Error())
+	}
+
+	var finished = make(chan bool)
+	go logStream("HOOK:", outReader, logFile, terminal, finished)
+	<-finished
+
+	if waitErr := hook.Wait(); waitErr != nil {
+		return fmt.Errorf("hook %q: %s", command, waitErr.Error())
+	}
+	return nil
+}
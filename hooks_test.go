@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	var rnr = Runner{
+		Hooks: []Hook{
+			{When: "PreLaunch", Command: "mount-image"},
+			{When: "PostExit", Command: "sync-saves"},
+		},
+	}
+	var targetExe = Exe{
+		Hooks: []Hook{
+			{When: "PreLaunch", Command: "switch-audio"},
+		},
+	}
+
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamWhen string
+	}{
+		{
+			Description: "hooks of winelarc before those of the entry",
+			Expected:    []string{"mount-image", "switch-audio"},
+			ParamWhen:   "PreLaunch",
+		},
+		{
+			Description: "no hooks at a time",
+			Expected:    nil,
+			ParamWhen:   "PostLaunch",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = rnr.hooks(targetExe, testCase.ParamWhen)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestRunFromListHooks(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		Hooks: []Hook{
+			{When: "PreLaunch", Command: "echo pre $WINELA_NAME $WINELA_ENTRY"},
+		},
+		List: []Exe{
+			{Name: "Script", Path: "exit 3", Hooks: []Hook{
+				{When: "PostLaunch", Command: "test -n \"$WINELA_PID\" && echo started"},
+				{When: "PostExit", Command: "echo exited with $WINELA_EXIT >&2"},
+			}},
+			{Name: "Stopped", Path: "touch " + inTestDir("ran"), Hooks: []Hook{
+				{When: "PreLaunch", Command: "echo not mounted; exit 1"},
+			}},
+			{Name: "Detached", Path: "echo out; sleep 0.2; echo more; exit 4", Hooks: []Hook{
+				{When: "PostLaunch", Command: "echo started"},
+				{When: "PostExit", Command: "echo exited with $WINELA_EXIT as $WINELA_PID >&2"},
+			}},
+		},
+	}
+
+	t.Run("hooks around a launch", func(t *testing.T) {
+		var gotten, gottenErr = rnr.runFromList(1, false)
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var logData, _ = ioutil.ReadFile(gotten.Log)
+		for _, expected := range []string{" HOOK: pre Script 1\n", " HOOK: started\n", " HOOK: exited with 3\n"} {
+			if !strings.Contains(string(logData), expected) {
+				errorExpGot(t, expected, string(logData), false)
+			}
+		}
+	})
+
+	t.Run("failing hook before the launch", func(t *testing.T) {
+		var _, gottenErr = rnr.runFromList(2, false)
+
+		var expectedErr = fmt.Errorf(`pre-launch hook "echo not mounted; exit 1": exit status 1`)
+		if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+			errorExpGot(t, expectedErr, gottenErr, true)
+		}
+
+		if _, statErr := os.Stat(inTestDir("ran")); statErr == nil {
+			errorExpGot(t, "not run", "run", false)
+		}
+
+		var logData, _ = ioutil.ReadFile(logsInDir(rnr.logDir(rnr.List[1]))[0])
+		if !strings.Contains(string(logData), " HOOK: not mounted\n") {
+			errorExpGot(t, "HOOK: not mounted", string(logData), false)
+		}
+	})
+
+	t.Run("hooks after a detached launch exits", func(t *testing.T) {
+		var gotten, gottenErr = rnr.runFromList(3, true)
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var logData []byte
+		for tries := 0; tries < 100 && !strings.Contains(string(logData), "exited with"); tries++ {
+			time.Sleep(20 * time.Millisecond)
+			logData, _ = ioutil.ReadFile(gotten.Log)
+		}
+
+		// with what the program wrote kept along with the hooks
+		var exited = fmt.Sprintf(" HOOK: exited with 4 as %d\n", gotten.Pid)
+		for _, expected := range []string{" HOOK: pre Detached 3\n", " HOOK: started\n", " OUT: out\n", " OUT: more\n", exited} {
+			if !strings.Contains(string(logData), expected) {
+				errorExpGot(t, expected, string(logData), false)
+			}
+		}
+	})
+
+	t.Run("hooks of winelarc run once after a detached launch exits", func(t *testing.T) {
+		// the winela writing the log reads the same winelarc
+		var configHome, _ = filepath.Abs(inTestDir("config"))
+		os.MkdirAll(path.Join(configHome, "winela"), 0755)
+		ioutil.WriteFile(path.Join(configHome, "winela", "winelarc"), []byte("PostExit = echo global exit\n"), 0644)
+		defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+
+		var globalRnr = rnr
+		globalRnr.Hooks = []Hook{{When: "PostExit", Command: "echo global exit"}}
+
+		var gotten, gottenErr = globalRnr.runFromList(3, true)
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var logData []byte
+		for tries := 0; tries < 100 && !strings.Contains(string(logData), "exited with"); tries++ {
+			time.Sleep(20 * time.Millisecond)
+			logData, _ = ioutil.ReadFile(gotten.Log)
+		}
+
+		if strings.Count(string(logData), " HOOK: global exit\n") != 1 {
+			errorExpGot(t, "the hook of winelarc run once", string(logData), false)
+		}
+	})
+}
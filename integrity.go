@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// what is done when an exe changed since it was added before
+// launching it, nothing (off), launching it with a warning or
+// refusing to launch it
+var verifyPolicies = []string{
+	"off", "warn", "refuse",
+}
+
+// what verifying an exe can find
+const (
+	verifyOk          = "ok"
+	verifyChanged     = "changed"
+	verifyMissing     = "missing"
+	verifyNotRecorded = "not recorded"
+)
+
+// check if a verify policy is known
+func isVerifyPolicy(value string) bool {
+	for _, policy := range verifyPolicies {
+		if value == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// get the verify policy of an exe, its own before that of winelarc
+func (r Runner) verifyPolicy(targetExe Exe) string {
+	switch {
+	case targetExe.Verify != "":
+		return targetExe.Verify
+	case r.Verify != "":
+		return r.Verify
+	default:
+		return "off"
+	}
+}
+
+// get the sha256 of a file in hex and its size
+func fileChecksum(fileName string) (sum string, size int64, retErr error) {
+	var file, openErr = os.Open(fileName)
+	if openErr != nil {
+		return "", 0, openErr
+	}
+	defer file.Close()
+
+	var hash = sha256.New()
+	size, retErr = io.Copy(hash, file)
+	if retErr != nil {
+		return "", 0, retErr
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), size, nil
+}
+
+// record the checksums of the exes of a list, the ones already in
+// the known list keeping what was recorded for them then, so adding
+// an exe again does not take in how it was changed
+func recordChecksums(list []Exe, known []Exe) []Exe {
+	var recorded = make(map[string]Exe)
+	for _, element := range known {
+		if element.Sha256 != "" {
+			recorded[element.Path] = element
+		}
+	}
+
+	for i := range list {
+		if before, found := recorded[list[i].Path]; found {
+			list[i].Sha256 = before.Sha256
+			list[i].Size = before.Size
+			continue
+		}
+
+		// exes that can't be read are left unrecorded
+		if sum, size, sumErr := fileChecksum(list[i].Path); sumErr == nil {
+			list[i].Sha256 = sum
+			list[i].Size = size
+		}
+	}
+
+	return list
+}
+
+// check an exe against the checksum recorded for it
+func verifyExe(targetExe Exe) string {
+	if targetExe.Sha256 == "" {
+		return verifyNotRecorded
+	}
+
+	var sum, size, sumErr = fileChecksum(targetExe.Path)
+	switch {
+	case os.IsNotExist(sumErr):
+		return verifyMissing
+	case sumErr != nil, size != targetExe.Size, sum != targetExe.Sha256:
+		return verifyChanged
+	default:
+		return verifyOk
+	}
+}
+
+// verify an exe before launching it by its policy, noting what was
+// found in the log and on the terminal, and refusing it if told
+func (r Runner) verifyBeforeLaunch(targetExe Exe, logFile io.Writer, terminal io.Writer) error {
+	var policy = r.verifyPolicy(targetExe)
+	if policy == "off" {
+		return nil
+	}
+
+	var status = verifyExe(targetExe)
+	if status != verifyChanged && status != verifyMissing {
+		return nil
+	}
+
+	var text = fmt.Sprintf("%s changed since it was added", targetExe.Path)
+	if status == verifyMissing {
+		text = fmt.Sprintf("%s is missing", targetExe.Path)
+	}
+	if logFile != nil {
+		io.WriteString(logFile, logLine("VERIFY:", text, time.Now()))
+	}
+	if policy == "refuse" {
+		return fmt.Errorf("%s", text)
+	}
+	fmt.Fprintln(terminal, "winela: warning:", text)
+
+	return nil
+}
+
+// return how each exe of a list verified as lines of text
+// with their numbers, and whether any changed or went missing
+func displayVerified(list []Exe, numbers []int) (ret string, problems bool) {
+	for i, element := range list {
+		var status = verifyExe(element)
+		if status == verifyChanged || status == verifyMissing {
+			problems = true
+		}
+		ret += fmt.Sprintf("%v %s: %s\n", numbers[i], element.Name, status)
+	}
+	return
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// the checksum of some other exe
+var testGameSum = "d1f1d3e2ac7b2bdc2bb2b8a9a4dcb4b2bd78ff8e6a20f0b8df5df3aa1d8a7e63"
+
+func TestRecordChecksums(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	ioutil.WriteFile(inTestDir("Game.exe"), []byte("MZ game"), 0644)
+	ioutil.WriteFile(inTestDir("Tool.exe"), []byte("MZ tool changed"), 0644)
+	var gameSum, gameSize, _ = fileChecksum(inTestDir("Game.exe"))
+
+	var known = []Exe{
+		{Name: "Tool", Path: inTestDir("Tool.exe"), Sha256: "abc", Size: 7},
+	}
+	var scanned = []Exe{
+		{Name: "Game", Path: inTestDir("Game.exe")},
+		{Name: "Tool", Path: inTestDir("Tool.exe")},
+		{Name: "Gone", Path: inTestDir("Gone.exe")},
+	}
+
+	var expected = []Exe{
+		{Name: "Game", Path: inTestDir("Game.exe"), Sha256: gameSum, Size: gameSize},
+		{Name: "Tool", Path: inTestDir("Tool.exe"), Sha256: "abc", Size: 7},
+		{Name: "Gone", Path: inTestDir("Gone.exe")},
+	}
+	var gotten = recordChecksums(scanned, known)
+
+	if fmt.Sprint(expected) != fmt.Sprint(gotten) || gameSize != 7 || len(gameSum) != 64 {
+		errorExpGot(t, expected, gotten, false)
+	}
+}
+
+func TestVerifyExe(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	ioutil.WriteFile(inTestDir("Game.exe"), []byte("MZ game"), 0644)
+	var gameSum, _, _ = fileChecksum(inTestDir("Game.exe"))
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamExe Exe
+	}{
+		{
+			Description: "same as recorded",
+			Expected:    verifyOk,
+			ParamExe:    Exe{Path: inTestDir("Game.exe"), Sha256: gameSum, Size: 7},
+		},
+		{
+			Description: "same size but other contents",
+			Expected:    verifyChanged,
+			ParamExe:    Exe{Path: inTestDir("Game.exe"), Sha256: testGameSum, Size: 7},
+		},
+		{
+			Description: "gone",
+			Expected:    verifyMissing,
+			ParamExe:    Exe{Path: inTestDir("Gone.exe"), Sha256: gameSum, Size: 7},
+		},
+		{
+			Description: "nothing recorded",
+			Expected:    verifyNotRecorded,
+			ParamExe:    Exe{Path: inTestDir("Game.exe")},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = verifyExe(testCase.ParamExe)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestVerifyBeforeLaunch(t *testing.T) {
+	var changed = Exe{Name: "Gone", Path: inTestDir("Gone.exe"), Sha256: testGameSum, Size: 7}
+
+	var testTable = []struct {
+		Description string
+		ExpectedLog string
+		ExpectedErr error
+
+		ParamPolicy string
+	}{
+		{
+			Description: "refused",
+			ExpectedLog: " VERIFY: testground/Gone.exe is missing\n",
+			ExpectedErr: fmt.Errorf("testground/Gone.exe is missing"),
+			ParamPolicy: "refuse",
+		},
+		{
+			Description: "warned about",
+			ExpectedLog: " VERIFY: testground/Gone.exe is missing\n",
+			ExpectedErr: nil,
+			ParamPolicy: "warn",
+		},
+		{
+			Description: "not checked",
+			ExpectedLog: "",
+			ExpectedErr: nil,
+			ParamPolicy: "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var logged, terminal bytes.Buffer
+			var gottenErr = Runner{Verify: testCase.ParamPolicy}.verifyBeforeLaunch(changed, &logged, &terminal)
+
+			if !strings.HasSuffix(logged.String(), testCase.ExpectedLog) || (testCase.ExpectedLog == "") != (logged.Len() == 0) {
+				errorExpGot(t, testCase.ExpectedLog, logged.String(), false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestVerifyBeforeDebugAndExec(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{
+		Program: "sh",
+		Verify:  "refuse",
+		List: []Exe{
+			{Name: "Gone", Path: inTestDir("Gone.exe"), Sha256: testGameSum, Size: 7},
+		},
+	}
+	var expectedErr = fmt.Errorf("testground/Gone.exe is missing")
+
+	t.Run("debugging refused", func(t *testing.T) {
+		var _, _, gottenErr = rnr.debugFromList(1, "+seh")
+
+		if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+			errorExpGot(t, expectedErr, gottenErr, true)
+		}
+	})
+
+	t.Run("tools refused", func(t *testing.T) {
+		var _, gottenErr = rnr.execInContext(1, []string{"true"})
+
+		if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+			errorExpGot(t, expectedErr, gottenErr, true)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// a link of a prefix to the host that was taken out to isolate it,
+// its path is relative to the prefix
+type IsolatedLink struct {
+	Path   string
+	Target string
+}
+
+// read an isolated link as written in the settings file
+func parseIsolatedLink(value string) (ret IsolatedLink, retErr error) {
+	var pair = strings.SplitN(value, "=>", 2)
+	if len(pair) != 2 {
+		return ret, fmt.Errorf("isolated link %q: needs a path and a target", value)
+	}
+
+	ret.Path = strings.TrimSpace(pair[0])
+	ret.Target = strings.TrimSpace(pair[1])
+	return
+}
+
+// write an isolated link the way it is read
+func (l IsolatedLink) String() string {
+	return l.Path + " => " + l.Target
+}
+
+// find the links of a prefix that expose the host to its programs,
+// drives showing the host root and user folders (Desktop, Documents etc.)
+// that link to the ones of the host user
+func hostLinks(prefixDir string) (retList []IsolatedLink, retErr error) {
+	// drives pointing at the root
+	var drives, drivesErr = readDosDevices(prefixDir)
+	if drivesErr != nil && !os.IsNotExist(drivesErr) {
+		return nil, drivesErr
+	}
+
+	for _, letter := range sortedDriveLetters(drives) {
+		if resolveExisting(drives[letter]) != "/" {
+			continue
+		}
+
+		var linkPath = path.Join("dosdevices", letter)
+		var target, _ = os.Readlink(path.Join(prefixDir, linkPath))
+		retList = append(retList, IsolatedLink{Path: linkPath, Target: target})
+	}
+
+	// folders of each user linking out of the prefix
+	var usersDir = path.Join("drive_c", "users")
+	var users, _ = ioutil.ReadDir(path.Join(prefixDir, usersDir))
+
+	var absPrefix, _ = filepath.Abs(prefixDir)
+	absPrefix = resolveExisting(absPrefix)
+
+	for _, user := range users {
+		if !user.IsDir() {
+			continue
+		}
+
+		var userDir = path.Join(usersDir, user.Name())
+		var folders, _ = ioutil.ReadDir(path.Join(prefixDir, userDir))
+
+		for _, folder := range folders {
+			if folder.Mode()&os.ModeSymlink == 0 {
+				continue
+			}
+
+			var linkPath = path.Join(userDir, folder.Name())
+			var target, linkErr = os.Readlink(path.Join(prefixDir, linkPath))
+			if linkErr != nil {
+				continue
+			}
+
+			// links staying inside the prefix expose nothing
+			var absTarget = target
+			if !path.IsAbs(absTarget) {
+				absTarget = path.Join(absPrefix, userDir, target)
+			}
+			absTarget = resolveExisting(absTarget)
+			if absTarget == absPrefix || strings.HasPrefix(absTarget, absPrefix+"/") {
+				continue
+			}
+
+			retList = append(retList, IsolatedLink{Path: linkPath, Target: target})
+		}
+	}
+
+	return
+}
+
+// get the letters of drives in order
+func sortedDriveLetters(drives map[string]string) (retList []string) {
+	for letter := 'a'; letter <= 'z'; letter++ {
+		if _, found := drives[string(letter)+":"]; found {
+			retList = append(retList, string(letter)+":")
+		}
+	}
+	return
+}
+
+// take out the links of a prefix to the host, drives are removed
+// and user folders replaced by private dirs, the links are
+// recorded in the settings so they can be put back
+func applyIsolation(prefixDir string, config *PrefixConfig) (retErr error) {
+	var links, linksErr = hostLinks(prefixDir)
+	if linksErr != nil {
+		return linksErr
+	}
+
+	for _, link := range links {
+		var fullPath = path.Join(prefixDir, link.Path)
+
+		if removeErr := os.Remove(fullPath); removeErr != nil {
+			return removeErr
+		}
+
+		// folders programs expect to exist become private ones
+		if !strings.HasPrefix(link.Path, "dosdevices/") {
+			if mkdirErr := os.Mkdir(fullPath, os.FileMode(0755)); mkdirErr != nil {
+				return mkdirErr
+			}
+		}
+
+		// keep the first target recorded for a path
+		var recorded bool
+		for _, isolated := range config.Isolated {
+			if isolated.Path == link.Path {
+				recorded = true
+			}
+		}
+		if !recorded {
+			config.Isolated = append(config.Isolated, link)
+		}
+	}
+
+	config.Isolation = true
+	return
+}
+
+// put back the links of a prefix taken out by isolating it,
+// private folders with files in them are kept next to the link
+func revertIsolation(prefixDir string, config *PrefixConfig) (retErr error) {
+	var notReverted []IsolatedLink
+
+	for _, link := range config.Isolated {
+		var fullPath = path.Join(prefixDir, link.Path)
+
+		// move the private folder out of the way
+		if info, statErr := os.Lstat(fullPath); statErr == nil {
+			if info.Mode()&os.ModeSymlink != 0 {
+				continue
+			}
+
+			if removeErr := os.Remove(fullPath); removeErr != nil {
+				if renameErr := os.Rename(fullPath, fullPath+".isolated"); renameErr != nil {
+					notReverted = append(notReverted, link)
+					retErr = renameErr
+					continue
+				}
+			}
+		}
+
+		if linkErr := os.Symlink(link.Target, fullPath); linkErr != nil {
+			notReverted = append(notReverted, link)
+			retErr = linkErr
+		}
+	}
+
+	config.Isolated = notReverted
+	config.Isolation = false
+	return
+}
+
+// return a report of what a prefix exposes of the host
+// as lines of text, problems is false if it exposes nothing
+func diagnoseIsolation(prefixDir string) (ret string, problems bool) {
+	var links, linksErr = hostLinks(prefixDir)
+	if linksErr != nil {
+		return fmt.Sprintf("prefix %s: %s\n", prefixDir, linksErr.Error()), true
+	}
+
+	var folders int
+	for _, link := range links {
+		if strings.HasPrefix(link.Path, "dosdevices/") {
+			ret += fmt.Sprintf("prefix %s: exposes the host root as %s\n", prefixDir, path.Base(link.Path))
+		} else {
+			folders++
+		}
+	}
+
+	if folders > 0 {
+		ret += fmt.Sprintf("prefix %s: links %d user folders to the host\n", prefixDir, folders)
+	}
+
+	return ret, len(links) > 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// make a prefix exposing the host root as z: and a user
+// with documents linking to the host and music inside the prefix
+func makeExposedPrefix(prefixDir string) {
+	var cwd, _ = os.Getwd()
+
+	os.MkdirAll(prefixDir+"/dosdevices", 0755)
+	os.MkdirAll(prefixDir+"/drive_c/users/u/AppData", 0755)
+	os.MkdirAll(prefixDir+"/drive_c/music", 0755)
+	os.MkdirAll(TestDir+"/home/Documents", 0755)
+
+	os.Symlink("../drive_c", prefixDir+"/dosdevices/c:")
+	os.Symlink("/", prefixDir+"/dosdevices/z:")
+	os.Symlink(cwd+"/"+TestDir+"/home/Documents", prefixDir+"/drive_c/users/u/Documents")
+	os.Symlink("../../music", prefixDir+"/drive_c/users/u/Music")
+}
+
+func TestHostLinks(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var cwd, _ = os.Getwd()
+	var prefixDir = inTestDir("prefix")
+	makeExposedPrefix(prefixDir)
+
+	var gotten, gottenErr = hostLinks(prefixDir)
+	var expected = fmt.Sprintf("[dosdevices/z: => / drive_c/users/u/Documents => %s/%s/home/Documents]", cwd, TestDir)
+
+	if fmt.Sprint(gotten) != expected {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+}
+
+func TestApplyRevertIsolation(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeExposedPrefix(prefixDir)
+
+	var before, _ = hostLinks(prefixDir)
+	var config PrefixConfig
+
+	// isolate twice to see it stays the same
+	var applyErr = applyIsolation(prefixDir, &config)
+	applyIsolation(prefixDir, &config)
+
+	if applyErr != nil {
+		errorExpGot(t, nil, applyErr, true)
+	}
+
+	if fmt.Sprint(config.Isolated) != fmt.Sprint(before) || !config.Isolation {
+		errorExpGot(t, before, config, false)
+	}
+
+	// nothing exposed and a private folder made
+	if after, _ := hostLinks(prefixDir); len(after) != 0 {
+		errorExpGot(t, "[]", after, false)
+	}
+
+	if info, statErr := os.Lstat(prefixDir + "/drive_c/users/u/Documents"); statErr != nil || !info.IsDir() {
+		errorExpGot(t, "private documents dir", info, false)
+	}
+
+	// something saved privately is kept when reverting
+	os.WriteFile(prefixDir+"/drive_c/users/u/Documents/save.dat", []byte{}, 0644)
+
+	var revertErr = revertIsolation(prefixDir, &config)
+
+	if revertErr != nil {
+		errorExpGot(t, nil, revertErr, true)
+	}
+
+	if reverted, _ := hostLinks(prefixDir); fmt.Sprint(reverted) != fmt.Sprint(before) {
+		errorExpGot(t, before, reverted, false)
+	}
+
+	if _, statErr := os.Stat(prefixDir + "/drive_c/users/u/Documents.isolated/save.dat"); statErr != nil {
+		errorExpGot(t, nil, statErr, true)
+	}
+
+	if len(config.Isolated) != 0 || config.Isolation {
+		errorExpGot(t, PrefixConfig{}, config, false)
+	}
+}
+
+func TestDiagnoseIsolation(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeExposedPrefix(prefixDir)
+
+	var gotten, gottenProblems = diagnoseIsolation(prefixDir)
+	var expected = fmt.Sprintf("prefix %[1]s: exposes the host root as z:\nprefix %[1]s: links 1 user folders to the host\n", prefixDir)
+
+	if expected != gotten {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	if !gottenProblems {
+		errorExpGot(t, true, gottenProblems, false)
+	}
+}
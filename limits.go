@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// how long before the time of a session runs out it is warned of
+var limitWarning = 5 * time.Minute
+
+// how long a session out of time is given to end before it is killed
+var limitGrace = 10 * time.Second
+
+// read a limit of time like 90m, 2h or 1h30m
+func parseLimit(value string) (time.Duration, error) {
+	var d, parseErr = time.ParseDuration(value)
+	if parseErr != nil || d <= 0 {
+		return 0, fmt.Errorf("limit %q: needs a time like 90m or 2h", value)
+	}
+	return d, nil
+}
+
+// check if a limit of time can be read
+func isLimit(value string) bool {
+	var _, parseErr = parseLimit(value)
+	return parseErr == nil
+}
+
+// get the midnight a day starts at
+func startOfDay(t time.Time) time.Time {
+	var year, month, day = t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// get how long an exe (or every exe for no path) was used on the
+// day up to a time, counting the sessions still running as well
+func (r Runner) usedToday(now time.Time, exePath string) (ret time.Duration, retErr error) {
+	var since = startOfDay(now)
+
+	var history, historyErr = r.readHistory(since)
+	if historyErr != nil {
+		return 0, historyErr
+	}
+	for _, l := range history {
+		if exePath == "" || l.Path == exePath {
+			ret += l.Duration
+		}
+	}
+
+	var sessions, sessionsErr = r.liveSessions()
+	if sessionsErr != nil {
+		return 0, sessionsErr
+	}
+	for _, s := range sessions {
+		if (exePath == "" || s.Path == exePath) && !s.Started.Before(since) {
+			ret += now.Sub(s.Started)
+		}
+	}
+
+	return
+}
+
+// get how long an exe may run when started at a time, the least of
+// its session limit and what is left of its own quota for the day and
+// of the global one (counting every exe), limited is false if nothing
+// limits it, the limit of the entry comes before that of winelarc
+func (r Runner) timeLeft(targetExe Exe, now time.Time) (ret time.Duration, limited bool, retErr error) {
+	var take = func(d time.Duration) {
+		if !limited || d < ret {
+			ret = d
+			limited = true
+		}
+	}
+
+	var limit = targetExe.Limit
+	if limit == "" {
+		limit = r.Limit
+	}
+	if d, parseErr := parseLimit(limit); parseErr == nil {
+		take(d)
+	}
+
+	var quotas = []struct {
+		Value string
+		Path  string
+	}{
+		{targetExe.Quota, targetExe.Path},
+		{r.Quota, ""},
+	}
+	for _, quota := range quotas {
+		var d, parseErr = parseLimit(quota.Value)
+		if parseErr != nil {
+			continue
+		}
+
+		var used, usedErr = r.usedToday(now, quota.Path)
+		if usedErr != nil {
+			return 0, false, usedErr
+		}
+		take(d - used)
+	}
+
+	// the time used is only known from the state dir
+	if limited && r.StateDir == "" {
+		return 0, false, fmt.Errorf("limits need a state dir to keep track of time")
+	}
+
+	return
+}
+
+// check an exe may be started now, refusing it with no time left or
+// while it runs already when it is to run alone, returning how long it
+// may run and the locks taken for it, to be tied to its session
+func (r Runner) admitLaunch(targetExe Exe) (left time.Duration, limited bool, locks []string, retErr error) {
+	left, limited, retErr = r.admitTime(targetExe)
+	if retErr != nil {
+		return left, limited, nil, retErr
+	}
+
+	locks, retErr = r.takeLocks(targetExe)
+	return
+}
+
+// check an exe has time left to run now, refusing it with none,
+// returning how long it may run
+func (r Runner) admitTime(targetExe Exe) (left time.Duration, limited bool, retErr error) {
+	left, limited, retErr = r.timeLeft(targetExe, time.Now())
+	if retErr != nil {
+		return 0, false, retErr
+	} else if limited && left <= 0 {
+		return left, limited, r.refuseLaunch(targetExe, time.Now())
+	}
+	return
+}
+
+// refuse to launch an exe with no time left, noting it in its logs
+func (r Runner) refuseLaunch(targetExe Exe, now time.Time) error {
+	var text = fmt.Sprintf("%s has no time left today", targetExe.Name)
+
+	if logFile, logErr := r.createLaunchLog(targetExe, now); logErr == nil {
+		io.WriteString(logFile, logLine("LIMIT:", "refused to launch, "+text, now))
+		logFile.Close()
+	}
+
+	return fmt.Errorf("%s", text)
+}
+
+// watch a session for running out of time, warning a bit before (and
+// running the warning hooks) and then stopping it with what it left
+// running in wine, each written to its log and to the terminal if
+// given, until done is closed
+func (r Runner) enforceLimit(s Session, left time.Duration, warnHooks []string, logFile io.Writer, terminal io.Writer, done chan bool) {
+	var warning = limitWarning
+	if left < 2*warning {
+		warning = left / 2
+	}
+
+	var note = func(text string) {
+		if terminal != nil {
+			fmt.Fprintln(terminal, "winela:", text)
+		}
+		if logFile != nil {
+			io.WriteString(logFile, logLine("LIMIT:", text, time.Now()))
+		}
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(left - warning):
+		var shown = warning.Round(time.Second).String()
+		if warning >= time.Minute {
+			shown = formatRuntime(warning)
+		}
+		note(fmt.Sprintf("%s has %s left", s.Name, shown))
+
+		var warnEnv = append(hookEnv("LimitWarning", s, ""), "WINELA_LEFT="+strconv.Itoa(int(warning.Round(time.Second)/time.Second)))
+		runHooks(warnHooks, warnEnv, logFile, terminal)
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(warning):
+		note(fmt.Sprintf("%s is out of time, stopping it", s.Name))
+		r.stopSession(s, limitGrace)
+		r.killWineserver(s.Prefix)
+	}
+}
+
+// watch the session of a detached program for running out of time,
+// done by the winela writing its log, which starts before the session
+// is recorded and so waits for it first
+func (r Runner) enforceLimitOfLog(logPath string, left time.Duration, warnHooks []string, done chan bool) {
+	var started = time.Now()
+
+	var s, found = r.sessionOfLog(logPath)
+	for !found {
+		select {
+		case <-done:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+		s, found = r.sessionOfLog(logPath)
+	}
+
+	var logFile, openErr = os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if openErr != nil {
+		return
+	}
+	defer logFile.Close()
+
+	r.enforceLimit(s, left-time.Since(started), warnHooks, logFile, nil, done)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    time.Duration
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "hours and minutes",
+			Expected:    90 * time.Minute,
+			ExpectedErr: nil,
+			ParamValue:  "1h30m",
+		},
+		{
+			Description: "no unit",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf(`limit "90": needs a time like 90m or 2h`),
+			ParamValue:  "90",
+		},
+		{
+			Description: "no time at all",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf(`limit "0s": needs a time like 90m or 2h`),
+			ParamValue:  "0s",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseLimit(testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestTimeLeft(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// an hour and a half of the game and half an hour of
+	// the editor used in the morning, yesterday not counting
+	var day = startOfDay(time.Now())
+	var now = day.Add(12 * time.Hour)
+	var history = Runner{StateDir: inTestDir("state")}
+	history.recordLaunch(Launch{Started: day.Add(-time.Hour), Duration: 5 * time.Hour, Name: "Game", Path: "/games/Game.exe"})
+	history.recordLaunch(Launch{Started: day.Add(8 * time.Hour), Duration: 90 * time.Minute, Name: "Game", Path: "/games/Game.exe"})
+	history.recordLaunch(Launch{Started: day.Add(10 * time.Hour), Duration: 30 * time.Minute, Name: "Editor", Path: "/apps/Editor.exe"})
+
+	var testTable = []struct {
+		Description     string
+		Expected        time.Duration
+		ExpectedLimited bool
+		ExpectedErr     error
+
+		ParamExe      Exe
+		ParamLimit    string
+		ParamQuota    string
+		ParamStateDir string
+	}{
+		{
+			Description:     "nothing limiting",
+			Expected:        0,
+			ExpectedLimited: false,
+			ExpectedErr:     nil,
+			ParamExe:        Exe{Name: "Game", Path: "/games/Game.exe"},
+			ParamStateDir:   inTestDir("state"),
+		},
+		{
+			Description:     "limit of the entry before the global one",
+			Expected:        45 * time.Minute,
+			ExpectedLimited: true,
+			ExpectedErr:     nil,
+			ParamExe:        Exe{Name: "Game", Path: "/games/Game.exe", Limit: "45m"},
+			ParamLimit:      "90m",
+			ParamStateDir:   inTestDir("state"),
+		},
+		{
+			Description:     "quota of the entry partly used",
+			Expected:        30 * time.Minute,
+			ExpectedLimited: true,
+			ExpectedErr:     nil,
+			ParamExe:        Exe{Name: "Game", Path: "/games/Game.exe", Quota: "2h"},
+			ParamLimit:      "90m",
+			ParamStateDir:   inTestDir("state"),
+		},
+		{
+			Description:     "global quota counting every entry used up",
+			Expected:        -30 * time.Minute,
+			ExpectedLimited: true,
+			ExpectedErr:     nil,
+			ParamExe:        Exe{Name: "Editor", Path: "/apps/Editor.exe", Quota: "1h"},
+			ParamQuota:      "90m",
+			ParamStateDir:   inTestDir("state"),
+		},
+		{
+			Description:     "limits with no state dir",
+			Expected:        0,
+			ExpectedLimited: false,
+			ExpectedErr:     fmt.Errorf("limits need a state dir to keep track of time"),
+			ParamExe:        Exe{Name: "Game", Path: "/games/Game.exe"},
+			ParamLimit:      "90m",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var rnr = Runner{
+				Limit:    testCase.ParamLimit,
+				Quota:    testCase.ParamQuota,
+				StateDir: testCase.ParamStateDir,
+			}
+
+			var gotten, gottenLimited, gottenErr = rnr.timeLeft(testCase.ParamExe, now)
+
+			if testCase.Expected != gotten || testCase.ExpectedLimited != gottenLimited {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestRunFromListLimited(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe, running past its limit
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		Hooks:       []Hook{{When: "LimitWarning", Command: `echo "warned $WINELA_LEFT"`}},
+		List: []Exe{
+			{Name: "Script", Path: "sleep 5", Limit: "2s", Quota: "1h"},
+		},
+	}
+
+	t.Run("stopped once out of time", func(t *testing.T) {
+		var gotten, gottenErr = rnr.runFromList(1, false)
+
+		if gotten.code() != 128+15 {
+			errorExpGot(t, "killed by signal 15", gotten, false)
+		}
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var logData, _ = ioutil.ReadFile(gotten.Log)
+		if !strings.Contains(string(logData), " LIMIT: Script has 1s left\n") ||
+			!strings.Contains(string(logData), " HOOK: warned 1\n") ||
+			!strings.Contains(string(logData), " LIMIT: Script is out of time, stopping it\n") {
+			errorExpGot(t, "a warning, its hook and the stop in the log", string(logData), false)
+		}
+	})
+
+	t.Run("stopped once out of time when detached", func(t *testing.T) {
+		var gotten, gottenErr = rnr.runFromList(1, true)
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var logData []byte
+		for tries := 0; tries < 200 && !strings.Contains(string(logData), "out of time"); tries++ {
+			time.Sleep(20 * time.Millisecond)
+			logData, _ = ioutil.ReadFile(gotten.Log)
+		}
+
+		if !strings.Contains(string(logData), " HOOK: warned 1\n") ||
+			!strings.Contains(string(logData), " LIMIT: Script is out of time, stopping it\n") {
+			errorExpGot(t, "the warning hook and the stop in the log", string(logData), false)
+		}
+	})
+
+	t.Run("refused once the quota is used up", func(t *testing.T) {
+		rnr.recordLaunch(Launch{Started: time.Now(), Duration: time.Hour, Name: "Script", Path: "sleep 5"})
+
+		var gotten, gottenErr = rnr.runFromList(1, false)
+
+		var expectedErr = fmt.Errorf("Script has no time left today")
+		if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+			errorExpGot(t, expectedErr, gottenErr, true)
+		}
+
+		var logs = logsInDir(rnr.logDir(rnr.List[0]))
+		var logData, _ = ioutil.ReadFile(logs[0])
+		if gotten.Pid != 0 || !strings.Contains(string(logData), " LIMIT: refused to launch, Script has no time left today\n") {
+			errorExpGot(t, "the refusal in the log", string(logData), false)
+		}
+	})
+
+	t.Run("debugging and tools refused once the quota is used up", func(t *testing.T) {
+		var expectedErr = fmt.Errorf("Script has no time left today")
+
+		var _, _, debugErr = rnr.debugFromList(1, "+seh")
+		var _, execErr = rnr.execInContext(1, []string{"true"})
+		if equalErrorList(t, []error{expectedErr, expectedErr}, []error{debugErr, execErr}) == false {
+			errorExpGot(t, expectedErr, []error{debugErr, execErr}, true)
+		}
+	})
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// how many logs are kept for each entry and how
+// much space they can take up unless set in the config
+var (
+	defaultLogKeep = 20
+	defaultLogSize = int64(50 << 20)
+)
+
+// get the dir the logs of an entry are kept in
+func (r Runner) logDir(targetExe Exe) string {
+	return path.Join(r.StateDir, "logs", entryFileName(targetExe))
+}
+
+// get the name of an entry with the chars that can't be
+// in a file name replaced, to name its dirs in the state dir
+func entryFileName(targetExe Exe) string {
+	var name = strings.NewReplacer("/", "_", "\x00", "_").Replace(targetExe.Name)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
+
+// make a log file for a launch of an exe, named by when it started,
+// which is appended to as the winela writing the log of a detached
+// program writes to it as well
+func (r Runner) createLaunchLog(targetExe Exe, started time.Time) (*os.File, error) {
+	var dir = r.logDir(targetExe)
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return nil, mkdirErr
+	}
+
+	var logName = path.Join(dir, started.Format("20060102-150405.000")+".log")
+	var logFile, createErr = os.OpenFile(logName, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0644)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	// make room for it
+	var keep, size = r.logLimits()
+	pruneLogs(dir, keep, size)
+
+	return logFile, nil
+}
+
+// write a line of output to a log with when it was
+// written and the stream it came from
+func logLine(tag string, text string, at time.Time) string {
+	return at.Format("2006-01-02T15:04:05.000") + " " + tag + " " + text + "\n"
+}
+
+// write the lines of a stream to a log as they come, and to the
+// terminal as well if given, sending on the channel once it ends
+func logStream(tag string, reader io.Reader, logFile io.Writer, terminal io.Writer, channel chan bool) {
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if terminal != nil {
+			fmt.Fprintln(terminal, tag, scanner.Text())
+		}
+		if logFile != nil {
+			io.WriteString(logFile, logLine(tag, scanner.Text(), time.Now()))
+		}
+	}
+	channel <- true
+}
+
+// write the output and errors a detached program gives to its
+// log, which is done by the winela started in the background to
+// start it (see --log-streams), the session of the program ends
+// once it and what it started stop writing them
+func writeStreamLog(fileName string, outReader io.Reader, errReader io.Reader) error {
+	var logFile, openErr = os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if openErr != nil {
+		return openErr
+	}
+	defer logFile.Close()
+
+	var finishedError = make(chan bool)
+	var finishedOutput = make(chan bool)
+	go logStream("ERR:", errReader, logFile, nil, finishedError)
+	go logStream("OUT:", outReader, logFile, nil, finishedOutput)
+	<-finishedError
+	<-finishedOutput
+
+	return nil
+}
+
+// read a size given in bytes or with a k, m or g after it
+func parseSize(value string) (int64, error) {
+	var multiplier = int64(1)
+	var number = strings.ToLower(strings.TrimSpace(value))
+
+	for suffix, size := range map[string]int64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30} {
+		if strings.HasSuffix(number, suffix) {
+			multiplier = size
+			number = strings.TrimSuffix(number, suffix)
+		}
+	}
+
+	var parsed, parseErr = strconv.ParseInt(number, 10, 64)
+	if parseErr != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s is not a size", value)
+	}
+
+	return parsed * multiplier, nil
+}
+
+// get the logs of launches in a log dir, the latest first
+func logsInDir(dir string) []string {
+	return logFilesInDir(dir, ".log")
+}
+
+// get every log kept in a log dir, of launches and the
+// compressed ones of debugging runs, the latest first
+func keptLogsInDir(dir string) []string {
+	return logFilesInDir(dir, ".log", ".debug.log.gz")
+}
+
+// get the files in a log dir with one of the endings, the latest first
+func logFilesInDir(dir string, endings ...string) (retList []string) {
+	var entries, _ = ioutil.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, ending := range endings {
+			if strings.HasSuffix(entry.Name(), ending) {
+				retList = append(retList, path.Join(dir, entry.Name()))
+				break
+			}
+		}
+	}
+
+	// named by when they started
+	sort.Sort(sort.Reverse(sort.StringSlice(retList)))
+	return
+}
+
+// remove the oldest logs of a log dir so that no more than keep
+// are left and they take no more than size, the latest is always kept
+func pruneLogs(dir string, keep int, size int64) (retErr error) {
+	var total int64
+	for i, logPath := range keptLogsInDir(dir) {
+		var info, statErr = os.Stat(logPath)
+		if statErr != nil {
+			continue
+		}
+		total += info.Size()
+
+		if i == 0 || (i < keep && total <= size) {
+			continue
+		}
+
+		if removeErr := os.Remove(logPath); removeErr != nil {
+			retErr = removeErr
+		}
+	}
+
+	return
+}
+
+// get how many logs of an entry to keep and the space they can take
+func (r Runner) logLimits() (keep int, size int64) {
+	keep, size = defaultLogKeep, defaultLogSize
+	if r.LogKeep > 0 {
+		keep = r.LogKeep
+	}
+	if r.LogSize > 0 {
+		size = r.LogSize
+	}
+	return
+}
+
+// write out a log, and if following it the lines added to it
+// as long as the program writing to it is running
+func (r Runner) showLog(logPath string, follow bool, out io.Writer) error {
+	var logFile, openErr = os.Open(logPath)
+	if openErr != nil {
+		return openErr
+	}
+	defer logFile.Close()
+
+	for {
+		if _, copyErr := io.Copy(out, logFile); copyErr != nil {
+			return copyErr
+		}
+
+		if !follow || !r.logInUse(logPath) {
+			// what was written while checking
+			var _, copyErr = io.Copy(out, logFile)
+			return copyErr
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// check if a program still running writes to a log
+func (r Runner) logInUse(logPath string) bool {
+	var sessions, _ = r.liveSessions()
+	for _, s := range sessions {
+		if s.Log == logPath {
+			return true
+		}
+	}
+	return false
+}
+
+// get a file as a writer, which is nil (writing nowhere) for no file
+func optionalWriter(file *os.File) io.Writer {
+	if file == nil {
+		return nil
+	}
+	return file
+}
+
+// what the winela writing the log of a detached program is given
+// on its input, the command to start, the session it is started as
+// to tell the hooks about and the hooks it runs
+type logWriterJob struct {
+	Command LaunchCommand
+	Session Session
+	Hooks   []Hook
+}
+
+// start winela in the background to start a detached program and
+// write its streams to its log, with the time the program has left
+// if it is limited, returning the pid of the program once started
+// or why it could not be
+func startLogWriter(logPath string, stateDir string, left time.Duration, job logWriterJob) (ret int, retErr error) {
+	var self, selfErr = os.Executable()
+	if selfErr != nil {
+		return 0, fmt.Errorf("could not start log writer: %s", selfErr.Error())
+	}
+
+	var jobReader, jobWriter, jobErr = os.Pipe()
+	if jobErr != nil {
+		return 0, fmt.Errorf("could not start log writer: %s", jobErr.Error())
+	}
+	defer jobWriter.Close()
+	var statusReader, statusWriter, statusErr = os.Pipe()
+	if statusErr != nil {
+		jobReader.Close()
+		return 0, fmt.Errorf("could not start log writer: %s", statusErr.Error())
+	}
+	defer statusReader.Close()
+
+	var logger = exec.Command(self, "--log-streams", logPath, stateDir, strconv.FormatInt(int64(left/time.Millisecond), 10))
+	logger.Stdin = jobReader
+	logger.ExtraFiles = []*os.File{statusWriter}
+	logger.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	var startErr = logger.Start()
+	jobReader.Close()
+	statusWriter.Close()
+	if startErr != nil {
+		return 0, fmt.Errorf("could not start log writer: %s", startErr.Error())
+	}
+	logger.Process.Release()
+
+	json.NewEncoder(jobWriter).Encode(job)
+	jobWriter.Close()
+
+	// it tells the pid of the program or why it could not start it
+	var status, _ = bufio.NewReader(statusReader).ReadString('\n')
+	status = strings.TrimSpace(status)
+	switch {
+	case strings.HasPrefix(status, "pid "):
+		return strconv.Atoi(strings.TrimPrefix(status, "pid "))
+	case strings.HasPrefix(status, "error "):
+		return 0, fmt.Errorf("%s", strings.TrimPrefix(status, "error "))
+	}
+	return 0, fmt.Errorf("log writer ended without starting the program")
+}
+
+// start a detached program and write its streams to its log as
+// the winela started for it, telling the pid of the program or
+// why it could not be started on status, then stop it once out
+// of time, end its session once it stopped writing and run the
+// hooks after it exits with how it ended
+func (r Runner) runLogWriter(logPath string, left time.Duration, job logWriterJob, status io.WriteCloser) int {
+	var commandToRun = r.makeCommand(job.Command.Argv, job.Command.envList())
+	commandToRun.Dir = job.Command.Dir
+	var outReader, _ = commandToRun.StdoutPipe()
+	var errReader, _ = commandToRun.StderrPipe()
+
+	// in a process group of its own to be stopped with what it started
+	commandToRun.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var startTime = time.Now()
+	if startErr := commandToRun.Start(); startErr != nil {
+		fmt.Fprintf(status, "error %s\n", startErr.Error())
+		status.Close()
+		return 3
+	}
+	fmt.Fprintf(status, "pid %d\n", commandToRun.Process.Pid)
+	status.Close()
+
+	var limitDone = make(chan bool)
+	if left > 0 {
+		go r.enforceLimitOfLog(logPath, left, r.hooks(Exe{}, "LimitWarning"), limitDone)
+	}
+
+	var logErr = writeStreamLog(logPath, outReader, errReader)
+	close(limitDone)
+	commandToRun.Wait()
+	if r.StateDir != "" {
+		r.endSessionOfLog(logPath, time.Now())
+	}
+	if logErr != nil {
+		return 3
+	}
+
+	if postExitHooks := r.hooks(Exe{}, "PostExit"); len(postExitHooks) > 0 {
+		var logFile, openErr = os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0644)
+		if openErr != nil {
+			return 3
+		}
+		defer logFile.Close()
+
+		var result = commandResult(commandToRun, startTime)
+		job.Session.Pid = commandToRun.Process.Pid
+		runHooks(postExitHooks, hookEnv("PostExit", job.Session, strconv.Itoa(result.code())), logFile, nil)
+	}
+
+	return 0
+}
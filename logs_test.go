@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogLine(t *testing.T) {
+	var at = time.Date(2026, 10, 19, 20, 30, 1, 250e6, time.Local)
+	var expected = "2026-10-19T20:30:01.250 ERR: fixme:d3d:something\n"
+	var gotten = logLine("ERR:", "fixme:d3d:something", at)
+
+	if expected != gotten {
+		errorExpGot(t, expected, gotten, false)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    int64
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "bytes",
+			Expected:    1000,
+			ExpectedErr: nil,
+			ParamValue:  "1000",
+		},
+		{
+			Description: "megabytes",
+			Expected:    5 << 20,
+			ExpectedErr: nil,
+			ParamValue:  "5M",
+		},
+		{
+			Description: "not a size",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf("lots is not a size"),
+			ParamValue:  "lots",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseSize(testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestPruneLogs(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamKeep int
+		ParamSize int64
+	}{
+		{
+			Description: "within limits",
+			Expected:    []string{"3.log", "2.debug.log.gz", "1.log"},
+			ParamKeep:   5,
+			ParamSize:   100,
+		},
+		{
+			Description: "too many logs",
+			Expected:    []string{"3.log", "2.debug.log.gz"},
+			ParamKeep:   2,
+			ParamSize:   100,
+		},
+		{
+			Description: "debug logs taking space",
+			Expected:    []string{"3.log", "2.debug.log.gz"},
+			ParamKeep:   5,
+			ParamSize:   20,
+		},
+		{
+			Description: "too big logs keep the latest",
+			Expected:    []string{"3.log"},
+			ParamKeep:   5,
+			ParamSize:   5,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.MkdirAll(TestDir, 0755)
+			defer os.RemoveAll(TestDir)
+
+			for _, name := range []string{"1.log", "2.debug.log.gz", "3.log"} {
+				ioutil.WriteFile(inTestDir(name), []byte("1234567890"), 0644)
+			}
+
+			var pruneErr = pruneLogs(TestDir, testCase.ParamKeep, testCase.ParamSize)
+
+			var gotten []string
+			for _, logPath := range keptLogsInDir(TestDir) {
+				gotten = append(gotten, path.Base(logPath))
+			}
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if pruneErr != nil {
+				errorExpGot(t, nil, pruneErr, true)
+			}
+		})
+	}
+}
+
+func TestRunFromListLog(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		List: []Exe{
+			{Name: "Script", Path: "echo out; echo err >&2"},
+		},
+	}
+
+	var gotten, gottenErr = rnr.runFromList(1, false)
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+
+	var shown bytes.Buffer
+	var showErr = rnr.showLog(gotten.Log, true, &shown)
+
+	if !strings.Contains(shown.String(), " OUT: out\n") || !strings.Contains(shown.String(), " ERR: err\n") {
+		errorExpGot(t, "OUT: out and ERR: err", shown.String(), false)
+	}
+
+	if logged := logsInDir(rnr.logDir(rnr.List[0])); len(logged) != 1 || logged[0] != gotten.Log {
+		errorExpGot(t, gotten.Log, logged, false)
+	}
+
+	if showErr != nil {
+		errorExpGot(t, nil, showErr, true)
+	}
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -18,8 +23,60 @@ func main() {
 	fmt.Println(`winela [opts]
 	-r   [num]   # run a program from the list
 	-R   [num]   # run a program without forking the process
+	-R   [num] --wait process|tree|wineserver
+	             # return once the program, what it started or its whole prefix ended
+	-r   [num] --report
+	             # print the session already running instead of failing (Single = entry|prefix)
+	-r   [num] --dry-run
+	show-command [num]
+	             # print the command a program would be run with
+	exec [num] -- command [args]
+	             # run a wine tool (winecfg, regedit...) or a command as a program is run
 	-s   [dir]   # scan a directory to populate list with
-	-l           # print out the list`)
+	scan [dir] [--prefix name] [--registry]
+	             # scan a prefix or the programs installed in its registry
+	scan [dir] --desktop
+	             # add the programs wine made launchers for
+	path [num] [path] [--prefix name]
+	             # convert between windows and unix paths of a prefix
+	drives [num] [set letter dir | unset letter] [--prefix name]
+	             # list or change the drive mappings of an entry or prefix
+	isolate [num] [--prefix name] [--revert]
+	             # cut off a prefix from the host root and user folders
+	reg get [num] [--prefix name] key [name]
+	reg set [num] [--prefix name] key name data
+	             # read or change the registry of a prefix
+	dll list [num] [--prefix name]
+	dll set [num] [--prefix name] dll order
+	dll unset [num] [--prefix name] dll
+	             # list or change the dll overrides of an entry or prefix
+	prefix dxvk|vkd3d install [num] [--prefix name] archive
+	prefix dxvk|vkd3d uninstall|version [num] [--prefix name]
+	             # install dxvk or vkd3d-proton into a prefix from a release archive
+	tricks [num] [--prefix name] verb...
+	tricks [num] [--prefix name] [--list | --replay]
+	             # run winetricks verbs in a prefix and list or redo the ones run
+	doctor       # check the known prefixes for problems
+	ps           # list the programs still running
+	stop [num] [--timeout seconds]
+	             # end a running program, killing its prefix if it does not end in time
+	verify [num] [--record]
+	             # check the programs are what they were when added, or record them as they are now
+	saves list|snapshot [num]
+	saves restore [num] [--snapshot k | --from file]
+	saves export [num] [--snapshot k] file
+	             # list, make, roll back to or copy out the snapshots of the saves of a program
+	logs [num] [--run k] [--follow] [--list]
+	             # print the log of the latest or an earlier run of a program
+	debug [num] --preset name
+	             # run a program with wine debug channels on into a compressed log
+	             # (presets relay-lite, seh, loaddll, d3d, file or DebugPreset in winelarc)
+	diagnose [num] [--run k]
+	             # look for known failures in the log of a run of a program
+	stats [--since date] [--csv]
+	             # print how much each program was used, in total and by day
+	-l   [--sort recent|used]
+	             # print out the list`)
 }
 
 // central function for usage of functions
@@ -27,58 +84,183 @@ func main() {
 func launch(rnr Runner, args []string) int {
 	switch args[0] {
 	case "-r", "-R":
+		var opts, plain = parseOptions(args[1:], "wait")
+		var _, dryRun = opts["dry-run"]
+		var _, report = opts["report"]
+
 		// alert if no number given
-		if len(args) == 1 {
+		if len(plain) == 0 {
 			fmt.Printf("input error: give a number to launch\n")
 			return 1
 		}
 
 		// convert given number
-		var convertedInt, convErr = strconv.Atoi(args[1])
+		var convertedInt, convErr = strconv.Atoi(plain[0])
 		if convErr != nil {
-			fmt.Printf("conversion error: %v is not a number\n", args[1])
+			fmt.Printf("conversion error: %v is not a number\n", plain[0])
 			return 2
 		}
 
+		// wait as told for this run only, over what the entry has
+		if strategy, hasWait := opts["wait"]; hasWait {
+			if !isWaitStrategy(strategy) {
+				fmt.Printf("input error: wait for process, tree or wineserver\n")
+				return 1
+			}
+			if convertedInt >= 1 && convertedInt <= len(rnr.List) {
+				rnr.List = append([]Exe{}, rnr.List...)
+				rnr.List[convertedInt-1].Wait = strategy
+			}
+		}
+
+		// only show what would be run
+		if dryRun {
+			return launch(rnr, []string{"show-command", plain[0]})
+		}
+
 		// run
 		switch args[0] {
 		// if "r" then fork
 		case "-r":
-			var runErr = rnr.runFromList(convertedInt, true)
-			if runErr != nil {
+			var result, runErr = rnr.runFromList(convertedInt, true)
+			if locked, isLocked := runErr.(lockedError); isLocked && report {
+				fmt.Printf("stat: number %v is running already\n%s", convertedInt, displaySessions([]Session{locked.Session}))
+				return 0
+			} else if runErr != nil {
 				fmt.Printf("run error: %s\n", runErr.Error())
 				return 3
 			}
-			fmt.Printf("stat: number %v was run\n", convertedInt)
+			fmt.Printf("stat: number %v was run as pid %v\n", convertedInt, result.Pid)
+			if result.Log != "" {
+				fmt.Printf("stat: output goes to %s\n", result.Log)
+			}
 		// if "R" then don't fork
 		case "-R":
 			fmt.Printf("stat: number %v will be run\n", convertedInt)
-			var runErr = rnr.runFromList(convertedInt, false)
-			if runErr != nil {
+			var result, runErr = rnr.runFromList(convertedInt, false)
+			if locked, isLocked := runErr.(lockedError); isLocked && report {
+				fmt.Printf("stat: number %v is running already\n%s", convertedInt, displaySessions([]Session{locked.Session}))
+				return 0
+			} else if runErr != nil {
 				fmt.Printf("run error: %s\n", runErr.Error())
 				return 3
 			}
-			fmt.Printf("stat: number %v finished running\n", convertedInt)
+			fmt.Printf("stat: number %v finished running %s\n", convertedInt, result.String())
+
+			// tell what went wrong if anything known did
+			if result.Log != "" {
+				printDiagnoses(rnr, result.Log)
+			}
+
+			// end the way the process did
+			return result.code()
 		}
 
-	case "-s":
-		// set target dir according to given value if any
-		// otherwise use user home dir
-		var dirToScan string
-		switch len(args) {
-		case 1:
-			fmt.Printf("stat: no scan dir given so assume default dir\n")
-			if rnr.DefaultDir == "" {
-				fmt.Printf("input error: no default dir found\n")
-				return 1
+	case "show-command":
+		// alert if no number given
+		if len(args) == 1 {
+			fmt.Printf("input error: give a number to show the command of\n")
+			return 1
+		}
+
+		var convertedInt, convErr = strconv.Atoi(args[1])
+		if convErr != nil {
+			fmt.Printf("conversion error: %v is not a number\n", args[1])
+			return 2
+		}
+
+		var command, commandErr = rnr.launchCommand(convertedInt)
+		if commandErr != nil {
+			fmt.Printf("show-command error: %s\n", commandErr.Error())
+			return 3
+		}
+
+		fmt.Println(command.shellLine())
+		fmt.Println(command.jsonLine())
+
+	case "exec":
+		var _, plain = parseOptions(args[1:])
+
+		// alert if nothing to run given
+		if len(plain) < 2 {
+			fmt.Printf("input error: give a number and a command to run\n")
+			return 1
+		}
+
+		var convertedInt, convErr = strconv.Atoi(plain[0])
+		if convErr != nil {
+			fmt.Printf("conversion error: %v is not a number\n", plain[0])
+			return 2
+		}
+
+		var result, execErr = rnr.execInContext(convertedInt, plain[1:])
+		if execErr != nil {
+			fmt.Printf("exec error: %s\n", execErr.Error())
+			return 3
+		}
+
+		// end the way the command did
+		return result.code()
+
+	case "-s", "scan":
+		var opts, plain = parseOptions(args[1:], "prefix")
+		var prefixName, hasPrefix = opts["prefix"]
+		var _, useRegistry = opts["registry"]
+		var _, useDesktop = opts["desktop"]
+
+		var list []Exe
+		var scanErr []error
+		var scanned string
+
+		switch {
+		case useRegistry:
+			// get the programs installed in the prefix from its registry
+			scanned = "registry of prefix " + rnr.prefixPath(prefixName)
+			list, scanErr = importFromRegistry(rnr.prefixPath(prefixName), prefixName)
+
+		case useDesktop:
+			// get the programs wine made launchers for when installing
+			var dirToScan = desktopProgramsDir()
+			if len(plain) > 0 {
+				dirToScan = plain[0]
+			}
+
+			scanned = "launchers in " + dirToScan
+			list, scanErr = importFromDesktop(dirToScan, rnr.prefixPath(""))
+
+			// refer to prefixes by name where possible
+			for i := range list {
+				list[i].Prefix = rnr.prefixName(list[i].Prefix)
+			}
+
+		default:
+			// set target dir according to given value if any
+			// otherwise use the prefix drive or user home dir
+			var dirToScan string
+			switch {
+			case len(plain) > 0:
+				dirToScan = plain[0]
+			case hasPrefix:
+				dirToScan = path.Join(rnr.prefixPath(prefixName), "drive_c")
+			default:
+				fmt.Printf("stat: no scan dir given so assume default dir\n")
+				if rnr.DefaultDir == "" {
+					fmt.Printf("input error: no default dir found\n")
+					return 1
+				}
+				dirToScan = rnr.DefaultDir
+			}
+
+			// do the scan
+			scanned = "dir " + dirToScan
+			list, scanErr = importFromScan(dirToScan)
+
+			// bind what was found to the prefix
+			for i := range list {
+				list[i].Prefix = prefixName
 			}
-			dirToScan = rnr.DefaultDir
-		case 2:
-			dirToScan = args[1]
 		}
 
-		// do the scan
-		var list, scanErr = importFromScan(dirToScan)
 		// output all errors if they exist
 		if scanErr != nil {
 			for _, e := range scanErr {
@@ -87,7 +269,19 @@ func launch(rnr Runner, args []string) int {
 			return 3
 		}
 
-		fmt.Printf("stat: dir %s was scanned\n", dirToScan)
+		fmt.Printf("stat: %s was scanned\n", scanned)
+
+		// keep the entries of the exes already in the list as they
+		// are, then record what the new ones are now to tell later
+		// if they changed
+		list = keepKnownExes(list, rnr.List)
+		list = recordChecksums(list, rnr.List)
+
+		// scans of a prefix only cover a part of the list
+		// so add to it instead of replacing it
+		if hasPrefix || useRegistry || useDesktop {
+			list = mergeExeLists(rnr.List, list)
+		}
 
 		// export the scanned dir to wineladb
 		var exportErr = exportToFile(rnr.ListFile, list)
@@ -98,9 +292,177 @@ func launch(rnr Runner, args []string) int {
 
 		fmt.Printf("stat: list exported to %s\n", rnr.ListFile)
 
+	case "path":
+		var opts, plain = parseOptions(args[1:], "prefix")
+		var prefixName, hasPrefix = opts["prefix"]
+
+		// alert if nothing to convert given
+		if len(plain) == 0 {
+			fmt.Printf("input error: give a number or a path to convert\n")
+			return 1
+		}
+
+		// a number stands for an entry, whose path is converted
+		// unless another one is given to convert in its prefix
+		var toConvert = plain[0]
+		if convertedInt, convErr := strconv.Atoi(plain[0]); convErr == nil && !hasPrefix {
+			var targetExe, findErr = rnr.exeByNumber(convertedInt)
+			if findErr != nil {
+				fmt.Printf("path error: %s\n", findErr.Error())
+				return 3
+			}
+
+			prefixName = targetExe.Prefix
+			toConvert = targetExe.Path
+			if len(plain) > 1 {
+				toConvert = plain[1]
+			}
+		}
+
+		// convert in the direction the path needs
+		var converted string
+		var pathErr error
+		if isWindowsPath(toConvert) {
+			converted, pathErr = unixFromWindows(rnr.prefixPath(prefixName), toConvert)
+		} else {
+			converted, pathErr = windowsFromUnix(rnr.prefixPath(prefixName), toConvert)
+		}
+
+		if pathErr != nil {
+			fmt.Printf("path error: %s\n", pathErr.Error())
+			return 3
+		}
+
+		fmt.Println(converted)
+
+	case "drives":
+		return launchDrives(rnr, args[1:])
+
+	case "isolate":
+		return launchIsolate(rnr, args[1:])
+
+	case "reg":
+		return launchReg(rnr, args[1:])
+
+	case "dll":
+		return launchDll(rnr, args[1:])
+
+	case "prefix":
+		return launchPrefix(rnr, args[1:])
+
+	case "tricks":
+		return launchTricks(rnr, args[1:])
+
+	case "doctor":
+		// report what each prefix exposes of the host
+		var problems bool
+		for _, prefixDir := range rnr.knownPrefixes() {
+			var report, prefixProblems = diagnoseIsolation(prefixDir)
+			if !prefixProblems {
+				report = fmt.Sprintf("prefix %s: isolated from the host\n", prefixDir)
+			}
+			problems = problems || prefixProblems
+			fmt.Print(report)
+		}
+
+		if problems {
+			fmt.Printf("stat: problems found, see isolate to fix exposed prefixes\n")
+			return 3
+		}
+		fmt.Printf("stat: no problems found\n")
+
+	case "ps":
+		var sessions, sessionsErr = rnr.liveSessions()
+		if sessionsErr != nil {
+			fmt.Printf("ps error: %s\n", sessionsErr.Error())
+			return 3
+		}
+		fmt.Print(displaySessions(sessions))
+
+	case "stop":
+		return launchStop(rnr, args[1:])
+
+	case "logs":
+		return launchLogs(rnr, args[1:])
+
+	case "saves":
+		return launchSaves(rnr, args[1:])
+
+	case "verify":
+		return launchVerify(rnr, args[1:])
+
+	case "debug":
+		return launchDebug(rnr, args[1:])
+
+	case "diagnose":
+		return launchDiagnose(rnr, args[1:])
+
+	case "stats":
+		var opts, _ = parseOptions(args[1:], "since")
+		var _, csv = opts["csv"]
+
+		var since time.Time
+		if value, hasSince := opts["since"]; hasSince {
+			var parsed, parseErr = time.ParseInLocation("2006-01-02", value, time.Local)
+			if parseErr != nil {
+				fmt.Printf("input error: %v is not a date like 2006-01-02\n", value)
+				return 1
+			}
+			since = parsed
+		}
+
+		var history, historyErr = rnr.readHistory(since)
+		if historyErr != nil {
+			fmt.Printf("stats error: %s\n", historyErr.Error())
+			return 3
+		}
+
+		if csv {
+			fmt.Print(displayStatsCSV(history))
+		} else {
+			fmt.Print(displayStats(history))
+		}
+
+	case "--log-streams":
+		// winela starting a detached program and writing its log, given
+		// the milliseconds it has left (0 when its time is not limited)
+		// and the program, its session and the hooks to run as json on
+		// its input, telling the pid of the program or why it could not
+		// start it on its file 3
+		if len(args) < 4 {
+			return 1
+		}
+
+		var left, convErr = strconv.ParseInt(args[3], 10, 64)
+		if convErr != nil {
+			return 2
+		}
+		var job logWriterJob
+		if decodeErr := json.NewDecoder(os.Stdin).Decode(&job); decodeErr != nil {
+			return 2
+		}
+
+		// the hooks of winelarc read at the start are among those
+		// given already, so only the given ones are run
+		rnr.StateDir = args[2]
+		rnr.Hooks = job.Hooks
+		return rnr.runLogWriter(args[1], time.Duration(left)*time.Millisecond, job, os.NewFile(3, "status"))
+
 	case "-l":
-		// print every exe in list
+		var opts, _ = parseOptions(args[1:], "sort")
+		var sortBy, sorted = opts["sort"]
+		if sorted && sortBy != "recent" && sortBy != "used" {
+			fmt.Printf("input error: sort by recent or used\n")
+			return 1
+		}
+
+		// print every exe in list, with how much
+		// they were used if that is known
+		var history, _ = rnr.readHistory(time.Time{})
 		var toDisplay = rnr.displayList()
+		if len(history) > 0 || sorted {
+			toDisplay = rnr.displayListStats(statsByPath(history), sortBy)
+		}
 		fmt.Print(toDisplay)
 
 		fmt.Printf("stat: list printed\n")
@@ -112,3 +474,822 @@ func launch(rnr Runner, args []string) int {
 
 	return 0
 }
+
+// split the arguments of a command into options (--name) and plain
+// arguments, options listed in valued take the argument after them
+// as value and everything after -- is taken as plain arguments
+func parseOptions(args []string, valued ...string) (opts map[string]string, plain []string) {
+	opts = make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		// stop taking options
+		if args[i] == "--" {
+			plain = append(plain, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(args[i], "--") {
+			plain = append(plain, args[i])
+			continue
+		}
+
+		var name = strings.TrimPrefix(args[i], "--")
+		opts[name] = ""
+
+		// take value for options that need one
+		for _, v := range valued {
+			if v == name && i+1 < len(args) {
+				i++
+				opts[name] = args[i]
+			}
+		}
+	}
+
+	return
+}
+
+// split arguments like parseOptions but only taking the options
+// winela knows (flags and the ones with a value), leaving the others
+// as plain arguments for the program they are passed on to
+func parseKnownOptions(args []string, flags []string, valued ...string) (opts map[string]string, plain []string) {
+	opts = make(map[string]string)
+	var known = append(append([]string{}, flags...), valued...)
+
+	for i := 0; i < len(args); i++ {
+		// stop taking options
+		if args[i] == "--" {
+			plain = append(plain, args[i+1:]...)
+			break
+		}
+
+		var name = strings.TrimPrefix(args[i], "--")
+		var isKnown bool
+		for _, k := range known {
+			isKnown = isKnown || (strings.HasPrefix(args[i], "--") && k == name)
+		}
+		if !isKnown {
+			plain = append(plain, args[i])
+			continue
+		}
+		opts[name] = ""
+
+		// take value for options that need one
+		for _, v := range valued {
+			if v == name && i+1 < len(args) {
+				i++
+				opts[name] = args[i]
+			}
+		}
+	}
+
+	return
+}
+
+// list or change the drive mappings declared for an entry
+// or for a prefix (when given --prefix instead of a number)
+func launchDrives(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "prefix")
+
+	// find the entry or prefix whose mappings are wanted
+	var target, plainLeft, code = findTarget(rnr, opts, plain, "drives")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+	var prefixConfig, configErr = readPrefixConfig(prefixDir)
+	if configErr != nil {
+		fmt.Printf("drives error: %s\n", configErr.Error())
+		return 3
+	}
+
+	// only listing
+	if len(plainLeft) == 0 {
+		var toDisplay, displayErr = displayDrives(prefixDir, mergeDriveMaps(prefixConfig.Drives, target.Exe.Drives))
+		if displayErr != nil {
+			fmt.Printf("drives error: %s\n", displayErr.Error())
+			return 3
+		}
+		fmt.Print(toDisplay)
+		return 0
+	}
+
+	// the declared mappings being changed
+	var declared = prefixConfig.Drives
+	if target.Number != 0 {
+		declared = target.Exe.Drives
+	}
+
+	switch {
+	case plainLeft[0] == "set" && len(plainLeft) == 3:
+		var absTarget, _ = filepath.Abs(plainLeft[2])
+		var drive, parseErr = parseDriveMap(plainLeft[1] + " " + absTarget)
+		if parseErr != nil {
+			fmt.Printf("input error: %s\n", parseErr.Error())
+			return 1
+		}
+		if _, statErr := os.Stat(drive.Target); statErr != nil {
+			fmt.Printf("input error: %s\n", statErr.Error())
+			return 1
+		}
+		declared = setDriveMap(declared, drive)
+
+	case plainLeft[0] == "unset" && len(plainLeft) == 2:
+		declared = unsetDriveMap(declared, plainLeft[1])
+
+	default:
+		fmt.Printf("input error: use set with a letter and a dir or unset with a letter\n")
+		return 1
+	}
+
+	// store the changed mappings where they came from
+	var saveErr error
+	if target.Number == 0 {
+		prefixConfig.Drives = declared
+		saveErr = prefixConfig.write(prefixDir)
+	} else {
+		rnr.List[target.Number-1].Drives = declared
+		saveErr = exportToFile(rnr.ListFile, rnr.List)
+	}
+
+	if saveErr != nil {
+		fmt.Printf("drives error: %s\n", saveErr.Error())
+		return 3
+	}
+
+	fmt.Printf("stat: drive mappings saved\n")
+	return 0
+}
+
+// isolate the prefix of an entry or a given prefix from the host,
+// or put back what isolating took out when given --revert
+func launchIsolate(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "prefix")
+	var _, revert = opts["revert"]
+
+	var target, _, code = findTarget(rnr, opts, plain, "isolate")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+	var prefixConfig, configErr = readPrefixConfig(prefixDir)
+	if configErr != nil {
+		fmt.Printf("isolate error: %s\n", configErr.Error())
+		return 3
+	}
+
+	var isolateErr error
+	if revert {
+		isolateErr = revertIsolation(prefixDir, &prefixConfig)
+	} else {
+		isolateErr = applyIsolation(prefixDir, &prefixConfig)
+	}
+
+	// save what was done even if not all of it worked
+	var writeErr = prefixConfig.write(prefixDir)
+
+	if isolateErr != nil {
+		fmt.Printf("isolate error: %s\n", isolateErr.Error())
+		return 3
+	}
+	if writeErr != nil {
+		fmt.Printf("isolate error: %s\n", writeErr.Error())
+		return 3
+	}
+
+	if revert {
+		fmt.Printf("stat: prefix %s is no longer isolated\n", prefixDir)
+	} else {
+		fmt.Printf("stat: prefix %s is isolated\n", prefixDir)
+	}
+	return 0
+}
+
+// read or change values in the registry of the prefix
+// of an entry or of a given prefix
+func launchReg(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "prefix")
+
+	if len(plain) == 0 {
+		fmt.Printf("input error: use get or set\n")
+		return 1
+	}
+
+	var target, rest, code = findTarget(rnr, opts, plain[1:], "reg")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+
+	// the default value is named @ on the command line
+	if len(rest) > 1 && rest[1] == "@" {
+		rest[1] = ""
+	}
+
+	switch {
+	case plain[0] == "get" && len(rest) == 1:
+		var toDisplay, regErr = displayRegKey(prefixDir, rest[0])
+		if regErr != nil {
+			fmt.Printf("reg error: %s\n", regErr.Error())
+			return 3
+		}
+		fmt.Print(toDisplay)
+
+	case plain[0] == "get" && len(rest) == 2:
+		var value, regErr = readRegValue(prefixDir, rest[0], rest[1])
+		if regErr != nil {
+			fmt.Printf("reg error: %s\n", regErr.Error())
+			return 3
+		}
+		fmt.Println(value)
+
+	case plain[0] == "set" && len(rest) == 3:
+		// the wineserver of a running prefix overwrites the files
+		// with its own registry when it ends
+		if prefixRunning(prefixDir) {
+			fmt.Printf("reg error: %s is running, end it before changing its registry\n", prefixDir)
+			return 3
+		}
+
+		var tweak = RegTweak{Key: rest[0], Name: rest[1], Data: regData(rest[2])}
+		var _, regErr = applyRegTweaks(prefixDir, []RegTweak{tweak})
+		if regErr != nil {
+			fmt.Printf("reg error: %s\n", regErr.Error())
+			return 3
+		}
+		fmt.Printf("stat: value set in %s\n", prefixDir)
+
+	default:
+		fmt.Printf("input error: use get with a key and maybe a name or set with a key, a name and data\n")
+		return 1
+	}
+
+	return 0
+}
+
+// list or change the dll overrides of an entry or of a
+// prefix (when given --prefix instead of a number)
+func launchDll(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "prefix")
+
+	if len(plain) == 0 {
+		fmt.Printf("input error: use list, set or unset\n")
+		return 1
+	}
+
+	var target, rest, code = findTarget(rnr, opts, plain[1:], "dll")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+	var prefixConfig, configErr = readPrefixConfig(prefixDir)
+	if configErr != nil {
+		fmt.Printf("dll error: %s\n", configErr.Error())
+		return 3
+	}
+
+	// the declared overrides being changed
+	var declared = prefixConfig.Dlls
+	if target.Number != 0 {
+		declared = target.Exe.Dlls
+	}
+
+	switch {
+	case plain[0] == "list" && len(rest) == 0:
+		fmt.Print(displayDllOverrides(rnr.Dlls, prefixConfig.Dlls, target.Exe.Dlls))
+		return 0
+
+	case plain[0] == "set" && len(rest) == 2:
+		var override, parseErr = parseDllOverride(rest[0] + " " + rest[1])
+		if parseErr != nil {
+			fmt.Printf("input error: %s\n", parseErr.Error())
+			return 1
+		}
+		declared = setDllOverride(declared, override)
+
+	case plain[0] == "unset" && len(rest) == 1:
+		declared = unsetDllOverride(declared, rest[0])
+
+	default:
+		fmt.Printf("input error: use list, set with a dll and an order or unset with a dll\n")
+		return 1
+	}
+
+	// store the changed overrides where they came from
+	var saveErr error
+	if target.Number == 0 {
+		prefixConfig.Dlls = declared
+		saveErr = prefixConfig.write(prefixDir)
+	} else {
+		rnr.List[target.Number-1].Dlls = declared
+		saveErr = exportToFile(rnr.ListFile, rnr.List)
+	}
+
+	if saveErr != nil {
+		fmt.Printf("dll error: %s\n", saveErr.Error())
+		return 3
+	}
+
+	fmt.Printf("stat: dll overrides saved\n")
+	return 0
+}
+
+// install an addon (dxvk, vkd3d) into the prefix of an entry or a given
+// prefix from its release archive, take it out or show its version
+func launchPrefix(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "prefix")
+
+	if len(plain) < 2 {
+		fmt.Printf("input error: use dxvk or vkd3d with install, uninstall or version\n")
+		return 1
+	}
+
+	var name = plain[0]
+	if _, known := addonArchives[name]; !known {
+		fmt.Printf("input error: %s is not dxvk or vkd3d\n", name)
+		return 1
+	}
+
+	var target, rest, code = findTarget(rnr, opts, plain[2:], "prefix")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+	var prefixConfig, configErr = readPrefixConfig(prefixDir)
+	if configErr != nil {
+		fmt.Printf("prefix error: %s\n", configErr.Error())
+		return 3
+	}
+
+	var addonErr error
+	switch {
+	case plain[1] == "install" && len(rest) == 1:
+		var installed Addon
+		installed, addonErr = installAddon(prefixDir, &prefixConfig, name, rest[0])
+		if addonErr == nil {
+			fmt.Printf("stat: %s %s installed in %s\n", name, installed.Version, prefixDir)
+		}
+
+	case plain[1] == "uninstall" && len(rest) == 0:
+		addonErr = uninstallAddon(prefixDir, &prefixConfig, name)
+		if addonErr == nil {
+			fmt.Printf("stat: %s taken out of %s\n", name, prefixDir)
+		}
+
+	case plain[1] == "version" && len(rest) == 0:
+		var installed, found = prefixConfig.addon(name)
+		if !found {
+			fmt.Printf("stat: %s is not installed in %s\n", name, prefixDir)
+			return 0
+		}
+		fmt.Println(installed.Version)
+		return 0
+
+	default:
+		fmt.Printf("input error: use install with an archive, uninstall or version\n")
+		return 1
+	}
+
+	// what was done to the prefix is recorded even when it failed
+	// part of the way, like a previous version taken out first
+	if writeErr := prefixConfig.write(prefixDir); writeErr != nil {
+		fmt.Printf("prefix error: %s\n", writeErr.Error())
+		return 3
+	}
+
+	if addonErr != nil {
+		fmt.Printf("prefix error: %s\n", addonErr.Error())
+		return 3
+	}
+
+	return 0
+}
+
+// run winetricks verbs in the prefix of an entry or a given prefix
+// and record them, or list or run again the ones recorded
+func launchTricks(rnr Runner, args []string) int {
+	// the options of winetricks are passed on with the verbs
+	var opts, plain = parseKnownOptions(args, []string{"list", "replay"}, "prefix")
+	var _, list = opts["list"]
+	var _, replay = opts["replay"]
+
+	var target, verbs, code = findTarget(rnr, opts, plain, "tricks")
+	if code != 0 {
+		return code
+	}
+
+	var prefixDir = rnr.prefixPath(target.PrefixName)
+	var prefixConfig, configErr = readPrefixConfig(prefixDir)
+	if configErr != nil {
+		fmt.Printf("tricks error: %s\n", configErr.Error())
+		return 3
+	}
+
+	switch {
+	case list:
+		for _, verb := range prefixConfig.Tricks {
+			fmt.Println(verb)
+		}
+		return 0
+	case replay:
+		verbs = prefixConfig.Tricks
+	}
+
+	if len(verbs) == 0 {
+		fmt.Printf("input error: give verbs to run\n")
+		return 1
+	}
+
+	// run the way the entry is, or anything run in the prefix
+	var targetExe = target.Exe
+	targetExe.Prefix = target.PrefixName
+	var runErr = rnr.winetricksCommand(targetExe, prefixConfig, verbs).Run()
+	if runErr != nil {
+		fmt.Printf("tricks error: could not run %s: %s\n", rnr.winetricksProgram(), runErr.Error())
+		return 3
+	}
+
+	prefixConfig.Tricks = recordTricks(prefixConfig.Tricks, verbs)
+	if writeErr := prefixConfig.write(prefixDir); writeErr != nil {
+		fmt.Printf("tricks error: %s\n", writeErr.Error())
+		return 3
+	}
+
+	fmt.Printf("stat: %s run in %s\n", strings.Join(verbs, " "), prefixDir)
+	return 0
+}
+
+// stop the running sessions of an entry, giving them
+// a number of seconds to end before killing them
+func launchStop(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "timeout")
+
+	if len(plain) == 0 {
+		fmt.Printf("input error: give a number to stop\n")
+		return 1
+	}
+
+	var convertedInt, convErr = strconv.Atoi(plain[0])
+	if convErr != nil {
+		fmt.Printf("conversion error: %v is not a number\n", plain[0])
+		return 2
+	}
+
+	var timeout = 10 * time.Second
+	if value, hasTimeout := opts["timeout"]; hasTimeout {
+		var seconds, timeoutErr = strconv.Atoi(value)
+		if timeoutErr != nil {
+			fmt.Printf("conversion error: %v is not a number\n", value)
+			return 2
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	var sessions, sessionsErr = rnr.liveSessions()
+	if sessionsErr != nil {
+		fmt.Printf("stop error: %s\n", sessionsErr.Error())
+		return 3
+	}
+
+	var stopped int
+	for _, s := range sessions {
+		if s.Entry != convertedInt {
+			continue
+		}
+		if stopErr := rnr.stopSession(s, timeout); stopErr != nil {
+			fmt.Printf("stop error: %s\n", stopErr.Error())
+			return 3
+		}
+		stopped++
+	}
+
+	if stopped == 0 {
+		fmt.Printf("stop error: number %v is not running\n", convertedInt)
+		return 3
+	}
+
+	fmt.Printf("stat: number %v was stopped\n", convertedInt)
+	return 0
+}
+
+// print the log of the latest run of an entry or of an
+// earlier one (--run 2 for the one before the latest),
+// following it while the program runs if wanted
+func launchLogs(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "run")
+	var _, follow = opts["follow"]
+	var _, list = opts["list"]
+
+	var target, _, code = findTarget(rnr, map[string]string{}, plain, "logs")
+	if code != 0 {
+		return code
+	}
+
+	var logList = logsInDir(rnr.logDir(target.Exe))
+	if len(logList) == 0 {
+		fmt.Printf("logs error: number %v has no logs\n", target.Number)
+		return 3
+	}
+
+	// number the runs the latest first
+	if list {
+		for index, logPath := range logList {
+			var size int64
+			if info, statErr := os.Stat(logPath); statErr == nil {
+				size = info.Size()
+			}
+			fmt.Printf("%v %s (%v bytes)\n", index+1, path.Base(logPath), size)
+		}
+		return 0
+	}
+
+	var run = 1
+	if value, hasRun := opts["run"]; hasRun {
+		var convertedInt, convErr = strconv.Atoi(value)
+		if convErr != nil {
+			fmt.Printf("conversion error: %v is not a number\n", value)
+			return 2
+		}
+		run = convertedInt
+	}
+
+	if run < 1 || run > len(logList) {
+		fmt.Printf("logs error: number %v has %v runs logged\n", target.Number, len(logList))
+		return 3
+	}
+
+	if showErr := rnr.showLog(logList[run-1], follow, os.Stdout); showErr != nil {
+		fmt.Printf("logs error: %s\n", showErr.Error())
+		return 3
+	}
+
+	return 0
+}
+
+// check one entry or every entry against the checksums recorded for
+// them, or record the checksums of what they are now (after updating)
+func launchVerify(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args)
+	var _, record = opts["record"]
+
+	// every entry unless a number is given
+	var list = rnr.List
+	var numbers []int
+	for i := range list {
+		numbers = append(numbers, i+1)
+	}
+	if len(plain) > 0 {
+		var target, _, code = findTarget(rnr, map[string]string{}, plain, "verify")
+		if code != 0 {
+			return code
+		}
+		list = []Exe{target.Exe}
+		numbers = []int{target.Number}
+	}
+
+	if record {
+		for i, number := range numbers {
+			var sum, size, sumErr = fileChecksum(list[i].Path)
+			if sumErr != nil {
+				fmt.Printf("verify error: %s\n", sumErr.Error())
+				return 3
+			}
+			rnr.List[number-1].Sha256 = sum
+			rnr.List[number-1].Size = size
+		}
+
+		if exportErr := exportToFile(rnr.ListFile, rnr.List); exportErr != nil {
+			fmt.Printf("verify error: %s\n", exportErr.Error())
+			return 3
+		}
+		fmt.Printf("stat: checksums recorded for %v entries\n", len(numbers))
+		return 0
+	}
+
+	var verified, problems = displayVerified(list, numbers)
+	fmt.Print(verified)
+	if problems {
+		return 3
+	}
+
+	return 0
+}
+
+// list or make the snapshots of the saves of an entry, put the saves of
+// one (or of an archive from elsewhere) back or copy one out of winela
+func launchSaves(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "snapshot", "from")
+
+	if len(plain) == 0 {
+		fmt.Printf("input error: use list, snapshot, restore or export\n")
+		return 1
+	}
+
+	var target, rest, code = findTarget(rnr, map[string]string{}, plain[1:], "saves")
+	if code != 0 {
+		return code
+	}
+	if len(target.Exe.Saves) == 0 {
+		fmt.Printf("saves error: number %v has no save locations\n", target.Number)
+		return 3
+	}
+
+	// the snapshot worked on, the latest unless told
+	var snapshots = savesInDir(rnr.saveDir(target.Exe))
+	var snapshot string
+	if plain[0] == "restore" || plain[0] == "export" {
+		var number = 1
+		if value, hasNumber := opts["snapshot"]; hasNumber {
+			var convertedInt, convErr = strconv.Atoi(value)
+			if convErr != nil {
+				fmt.Printf("conversion error: %v is not a number\n", value)
+				return 2
+			}
+			number = convertedInt
+		}
+
+		if from, hasFrom := opts["from"]; hasFrom && plain[0] == "restore" {
+			snapshot = from
+		} else if number < 1 || number > len(snapshots) {
+			fmt.Printf("saves error: number %v has %v snapshots\n", target.Number, len(snapshots))
+			return 3
+		} else {
+			snapshot = snapshots[number-1]
+		}
+	}
+
+	switch {
+	case plain[0] == "list" && len(rest) == 0:
+		// number the snapshots the latest first
+		for index, snapshotPath := range snapshots {
+			var size int64
+			if info, statErr := os.Stat(snapshotPath); statErr == nil {
+				size = info.Size()
+			}
+			fmt.Printf("%v %s (%v bytes)\n", index+1, path.Base(snapshotPath), size)
+		}
+
+	case plain[0] == "snapshot" && len(rest) == 0:
+		var made, snapshotErr = rnr.snapshotSaves(target.Exe, time.Now())
+		if snapshotErr != nil {
+			fmt.Printf("saves error: %s\n", snapshotErr.Error())
+			return 3
+		}
+		fmt.Printf("stat: saves snapshotted to %s\n", made)
+
+	case plain[0] == "restore" && len(rest) == 0:
+		// saves in use are not swapped under the program
+		var sessions, _ = rnr.liveSessions()
+		for _, s := range sessions {
+			if s.Path == target.Exe.Path {
+				fmt.Printf("saves error: number %v is running as pid %v\n", target.Number, s.Pid)
+				return 3
+			}
+		}
+
+		if restoreErr := rnr.restoreSaves(target.Exe, snapshot); restoreErr != nil {
+			fmt.Printf("saves error: %s\n", restoreErr.Error())
+			return 3
+		}
+		fmt.Printf("stat: saves restored from %s\n", snapshot)
+
+	case plain[0] == "export" && len(rest) == 1:
+		if copyErr := copyFile(snapshot, rest[0]); copyErr != nil {
+			fmt.Printf("saves error: %s\n", copyErr.Error())
+			return 3
+		}
+		fmt.Printf("stat: saves exported to %s\n", rest[0])
+
+	default:
+		fmt.Printf("input error: use list, snapshot, restore or export with a file\n")
+		return 1
+	}
+
+	return 0
+}
+
+// run an entry with the wine debug channels of a preset on,
+// printing where the output went and what came up most in it
+func launchDebug(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "preset")
+
+	var presetName, hasPreset = opts["preset"]
+	var channels, found = rnr.debugPreset(presetName)
+	if !hasPreset || !found {
+		fmt.Printf("input error: give a preset out of %s\n", strings.Join(rnr.debugPresetNames(), ", "))
+		return 1
+	}
+
+	var target, _, code = findTarget(rnr, map[string]string{}, plain, "debug")
+	if code != 0 {
+		return code
+	}
+
+	fmt.Printf("stat: number %v will be run with WINEDEBUG=%s\n", target.Number, channels)
+	var result, summary, debugErr = rnr.debugFromList(target.Number, channels)
+	if debugErr != nil {
+		fmt.Printf("debug error: %s\n", debugErr.Error())
+		return 3
+	}
+
+	fmt.Print(summary.String())
+	fmt.Printf("stat: number %v finished running %s\n", target.Number, result.String())
+	fmt.Printf("stat: output written to %s\n", result.Log)
+	return result.code()
+}
+
+// look for known failures in the log of the latest run of an
+// entry or of an earlier one (--run 2 for the one before the latest)
+func launchDiagnose(rnr Runner, args []string) int {
+	var opts, plain = parseOptions(args, "run")
+
+	var target, _, code = findTarget(rnr, map[string]string{}, plain, "diagnose")
+	if code != 0 {
+		return code
+	}
+
+	var logList = logsInDir(rnr.logDir(target.Exe))
+	var run = 1
+	if value, hasRun := opts["run"]; hasRun {
+		var convertedInt, convErr = strconv.Atoi(value)
+		if convErr != nil {
+			fmt.Printf("conversion error: %v is not a number\n", value)
+			return 2
+		}
+		run = convertedInt
+	}
+
+	if run < 1 || run > len(logList) {
+		fmt.Printf("diagnose error: number %v has %v runs logged\n", target.Number, len(logList))
+		return 3
+	}
+
+	if !printDiagnoses(rnr, logList[run-1]) {
+		fmt.Printf("stat: no known failures found\n")
+	}
+	return 0
+}
+
+// print what the failures found in a launch log mean,
+// returning whether any were found
+func printDiagnoses(rnr Runner, logPath string) bool {
+	var diagnoses, diagnosesErr = rnr.diagnoses()
+	if diagnosesErr != nil {
+		fmt.Printf("diagnose error: %s\n", diagnosesErr.Error())
+	}
+
+	var found, diagnoseErr = diagnoseLog(logPath, diagnoses)
+	if diagnoseErr != nil {
+		fmt.Printf("diagnose error: %s\n", diagnoseErr.Error())
+	}
+
+	for _, text := range found {
+		fmt.Printf("diagnosis: %s\n", text)
+	}
+
+	return len(found) > 0
+}
+
+// the entry or prefix a command works on
+type commandTarget struct {
+	Exe        Exe
+	Number     int
+	PrefixName string
+}
+
+// find what a command works on, which is the entry whose number is
+// the first plain argument or else the prefix given with --prefix
+// (then number is 0), returns the plain arguments left and the
+// exit code to return when it could not be found
+func findTarget(rnr Runner, opts map[string]string, plain []string, command string) (ret commandTarget, rest []string, code int) {
+	if prefixName, hasPrefix := opts["prefix"]; hasPrefix {
+		ret.PrefixName = prefixName
+		return ret, plain, 0
+	}
+
+	if len(plain) == 0 {
+		fmt.Printf("input error: give a number or a prefix\n")
+		return ret, plain, 1
+	}
+
+	var convertedInt, convErr = strconv.Atoi(plain[0])
+	if convErr != nil {
+		fmt.Printf("conversion error: %v is not a number\n", plain[0])
+		return ret, plain, 2
+	}
+
+	var targetExe, findErr = rnr.exeByNumber(convertedInt)
+	if findErr != nil {
+		fmt.Printf("%s error: %s\n", command, findErr.Error())
+		return ret, plain, 3
+	}
+
+	ret.Exe = targetExe
+	ret.Number = convertedInt
+	ret.PrefixName = targetExe.Prefix
+	return ret, plain[1:], 0
+}
@@ -1,10 +1,29 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 )
 
+// let the test binary stand in for winela when winela starts itself,
+// reading its config the way winela does
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "--log-streams" {
+		os.Exit(launch(runnerInitMake(), os.Args[1:]))
+	}
+	// hold a lock on a file like a running wineserver does
+	if len(os.Args) > 2 && os.Args[1] == "--hold-lock" {
+		var lockFile, _ = os.OpenFile(os.Args[2], os.O_RDWR, 0)
+		syscall.FcntlFlock(lockFile.Fd(), syscall.F_SETLKW, &syscall.Flock_t{Type: syscall.F_WRLCK})
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
 func TestLaunch(t *testing.T) {
 	// make testing directory
 	os.MkdirAll(TestDir, 0755)
@@ -26,6 +45,15 @@ func TestLaunch(t *testing.T) {
 				ListFile: inTestDir("wineladb"),
 			},
 		},
+		{
+			Description: "list option with an unknown sort",
+			Expected:    1,
+
+			ParamArguments: []string{"-l", "--sort", "name"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
 		{
 			Description: "run option with no arguments",
 			Expected:    1,
@@ -53,6 +81,149 @@ func TestLaunch(t *testing.T) {
 				ListFile: inTestDir("wineladb"),
 			},
 		},
+		{
+			Description: "path option with nothing to convert",
+			Expected:    1,
+
+			ParamArguments: []string{"path"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "path option with a number out of the list",
+			Expected:    3,
+
+			ParamArguments: []string{"path", "4"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "isolate option with neither a number nor a prefix",
+			Expected:    1,
+
+			ParamArguments: []string{"isolate", "--revert"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "dll option with a misspelled order",
+			Expected:    1,
+
+			ParamArguments: []string{"dll", "set", "--prefix", "testground/prefix", "d3d9", "nativ"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "dll option with nothing to do",
+			Expected:    1,
+
+			ParamArguments: []string{"dll"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "exec option with no command",
+			Expected:    1,
+
+			ParamArguments: []string{"exec", "1"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "exec option with a number out of the list",
+			Expected:    3,
+
+			ParamArguments: []string{"exec", "4", "--", "winecfg"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "exec option ending with the exit code of the command",
+			Expected:    7,
+
+			ParamArguments: []string{"exec", "1", "--", "sh", "-c", "exit 7"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+				List:     []Exe{{Name: "Game", Path: "/games/game.exe"}},
+			},
+		},
+		{
+			Description: "dry run with a number out of the list",
+			Expected:    3,
+
+			ParamArguments: []string{"-r", "4", "--dry-run"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "stop option with a timeout that is not a number",
+			Expected:    2,
+
+			ParamArguments: []string{"stop", "1", "--timeout", "soon"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "run option with an unknown wait",
+			Expected:    1,
+
+			ParamArguments: []string{"-R", "1", "--wait", "forever"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "logs option for an entry with no logs",
+			Expected:    3,
+
+			ParamArguments: []string{"logs", "1"},
+			ParamRunner: Runner{
+				StateDir: inTestDir("state"),
+				List:     []Exe{{Name: "Game", Path: "/games/Game.exe"}},
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "saves option for an entry with no save locations",
+			Expected:    3,
+
+			ParamArguments: []string{"saves", "list", "1"},
+			ParamRunner: Runner{
+				StateDir: inTestDir("state"),
+				List:     []Exe{{Name: "Game", Path: "/games/Game.exe"}},
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "verify option with an entry gone missing",
+			Expected:    3,
+
+			ParamArguments: []string{"verify"},
+			ParamRunner: Runner{
+				List: []Exe{
+					{Name: "Game", Path: "/games/Game.exe", Sha256: "abc", Size: 7},
+				},
+				ListFile: inTestDir("wineladb"),
+			},
+		},
+		{
+			Description: "debug option with an unknown preset",
+			Expected:    1,
+
+			ParamArguments: []string{"debug", "1", "--preset", "everything"},
+			ParamRunner: Runner{
+				ListFile: inTestDir("wineladb"),
+			},
+		},
 		{
 			Description: "scan option no directory given and no default",
 			Expected:    1,
@@ -74,3 +245,99 @@ func TestLaunch(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKnownOptions(t *testing.T) {
+	var testTable = []struct {
+		Description   string
+		ExpectedOpts  map[string]string
+		ExpectedPlain []string
+
+		ParamArguments []string
+	}{
+		{
+			Description:   "options of another program are left plain",
+			ExpectedOpts:  map[string]string{"list": ""},
+			ExpectedPlain: []string{"3", "--force", "vcrun2019"},
+
+			ParamArguments: []string{"3", "--force", "--list", "vcrun2019"},
+		},
+		{
+			Description:   "known option with a value",
+			ExpectedOpts:  map[string]string{"prefix": "games"},
+			ExpectedPlain: []string{"-q", "corefonts"},
+
+			ParamArguments: []string{"--prefix", "games", "-q", "corefonts"},
+		},
+		{
+			Description:   "everything after double dash is plain",
+			ExpectedOpts:  map[string]string{},
+			ExpectedPlain: []string{"3", "--replay"},
+
+			ParamArguments: []string{"3", "--", "--replay"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gottenOpts, gottenPlain = parseKnownOptions(testCase.ParamArguments, []string{"list", "replay"}, "prefix")
+
+			if fmt.Sprint(testCase.ExpectedOpts) != fmt.Sprint(gottenOpts) {
+				errorExpGot(t, testCase.ExpectedOpts, gottenOpts, false)
+			}
+
+			if fmt.Sprint(testCase.ExpectedPlain) != fmt.Sprint(gottenPlain) {
+				errorExpGot(t, testCase.ExpectedPlain, gottenPlain, false)
+			}
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	var testTable = []struct {
+		Description   string
+		ExpectedOpts  map[string]string
+		ExpectedPlain []string
+
+		ParamArguments []string
+		ParamValued    []string
+	}{
+		{
+			Description:   "options with and without values between plain arguments",
+			ExpectedOpts:  map[string]string{"prefix": "games", "registry": ""},
+			ExpectedPlain: []string{"~/Games"},
+
+			ParamArguments: []string{"--registry", "~/Games", "--prefix", "games"},
+			ParamValued:    []string{"prefix"},
+		},
+		{
+			Description:   "everything after double dash is plain",
+			ExpectedOpts:  map[string]string{"dry-run": ""},
+			ExpectedPlain: []string{"3", "--prefix", "x"},
+
+			ParamArguments: []string{"--dry-run", "3", "--", "--prefix", "x"},
+			ParamValued:    []string{"prefix"},
+		},
+		{
+			Description:   "valued option with no value left",
+			ExpectedOpts:  map[string]string{"prefix": ""},
+			ExpectedPlain: nil,
+
+			ParamArguments: []string{"--prefix"},
+			ParamValued:    []string{"prefix"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gottenOpts, gottenPlain = parseOptions(testCase.ParamArguments, testCase.ParamValued...)
+
+			if fmt.Sprint(testCase.ExpectedOpts) != fmt.Sprint(gottenOpts) {
+				errorExpGot(t, testCase.ExpectedOpts, gottenOpts, false)
+			}
+
+			if fmt.Sprint(testCase.ExpectedPlain) != fmt.Sprint(gottenPlain) {
+				errorExpGot(t, testCase.ExpectedPlain, gottenPlain, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// settings winela keeps for a prefix, stored in a file inside it
+type PrefixConfig struct {
+	Drives    []DriveMap
+	Dlls      []DllOverride
+	Isolation bool
+	Isolated  []IsolatedLink
+	Addons    []Addon
+	Tricks    []string
+}
+
+// get the file the settings of a prefix are stored in
+func prefixConfigFile(prefixDir string) string {
+	return path.Join(prefixDir, "winelarc")
+}
+
+// read the settings of a prefix, a prefix
+// with no settings file has empty settings
+func readPrefixConfig(prefixDir string) (ret PrefixConfig, retErr error) {
+	var readData, readErr = ioutil.ReadFile(prefixConfigFile(prefixDir))
+	if os.IsNotExist(readErr) {
+		return
+	} else if readErr != nil {
+		return ret, readErr
+	}
+
+	for _, line := range strings.Split(string(readData), "\n") {
+		// split into pairs
+		var pair = strings.SplitN(line, "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		var left = strings.TrimSpace(pair[0])
+		var right = strings.TrimSpace(pair[1])
+
+		switch left {
+		case "Drive":
+			var drive, parseErr = parseDriveMap(right)
+			if parseErr != nil {
+				return ret, fmt.Errorf("%s: %s", prefixConfigFile(prefixDir), parseErr.Error())
+			}
+			ret.Drives = append(ret.Drives, drive)
+		case "Dll":
+			var override, parseErr = parseDllOverride(right)
+			if parseErr != nil {
+				return ret, fmt.Errorf("%s: %s", prefixConfigFile(prefixDir), parseErr.Error())
+			}
+			ret.Dlls = setDllOverride(ret.Dlls, override)
+		case "Isolation":
+			ret.Isolation, _ = strconv.ParseBool(right)
+		case "Addon":
+			var addon, parseErr = parseAddon(right)
+			if parseErr != nil {
+				return ret, fmt.Errorf("%s: %s", prefixConfigFile(prefixDir), parseErr.Error())
+			}
+			ret.Addons = append(ret.Addons, addon)
+		case "Tricks":
+			ret.Tricks = recordTricks(ret.Tricks, strings.Fields(right))
+		case "IsolatedLink":
+			var link, parseErr = parseIsolatedLink(right)
+			if parseErr != nil {
+				return ret, fmt.Errorf("%s: %s", prefixConfigFile(prefixDir), parseErr.Error())
+			}
+			ret.Isolated = append(ret.Isolated, link)
+		}
+	}
+
+	return
+}
+
+// write the settings of a prefix into its settings file
+func (c PrefixConfig) write(prefixDir string) error {
+	var strList string
+
+	if c.Isolation {
+		strList += fmt.Sprintf("Isolation = %v\n", c.Isolation)
+	}
+
+	for _, drive := range c.Drives {
+		strList += fmt.Sprintf("Drive = %s\n", drive.String())
+	}
+
+	for _, override := range c.Dlls {
+		strList += fmt.Sprintf("Dll = %s\n", override.String())
+	}
+
+	for _, link := range c.Isolated {
+		strList += fmt.Sprintf("IsolatedLink = %s\n", link.String())
+	}
+
+	if len(c.Tricks) > 0 {
+		strList += fmt.Sprintf("Tricks = %s\n", strings.Join(c.Tricks, " "))
+	}
+
+	for _, addon := range c.Addons {
+		strList += fmt.Sprintf("Addon = %s\n", addon.String())
+	}
+
+	return ioutil.WriteFile(prefixConfigFile(prefixDir), []byte(strList), os.FileMode(0644))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestReadPrefixConfig(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+
+	var testTable = []struct {
+		Description string
+		Expected    PrefixConfig
+		ExpectedErr error
+
+		ParamContent string
+	}{
+		{
+			Description: "prefix with no settings file",
+			Expected:    PrefixConfig{},
+			ExpectedErr: nil,
+
+			ParamContent: "",
+		},
+		{
+			Description: "prefix with drives set",
+			Expected: PrefixConfig{
+				Drives: []DriveMap{
+					{Letter: "d:", Target: "/mnt/cd"},
+					{Letter: "g:", Target: "/games"},
+				},
+			},
+			ExpectedErr: nil,
+
+			ParamContent: "Drive = d: /mnt/cd\nUnknown = value\nDrive = g: /games\n",
+		},
+		{
+			Description: "prefix with a broken drive",
+			Expected:    PrefixConfig{},
+			ExpectedErr: fmt.Errorf(`%s: drive "d:": needs a letter and a dir`, prefixConfigFile(prefixDir)),
+
+			ParamContent: "Drive = d:\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.MkdirAll(prefixDir, 0755)
+			defer os.RemoveAll(prefixDir)
+
+			if testCase.ParamContent != "" {
+				os.WriteFile(prefixConfigFile(prefixDir), []byte(testCase.ParamContent), 0644)
+			}
+
+			var gotten, gottenErr = readPrefixConfig(prefixDir)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestPrefixConfigWrite(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	os.MkdirAll(prefixDir, 0755)
+
+	var written = PrefixConfig{
+		Drives: []DriveMap{
+			{Letter: "d:", Target: "/mnt/game cd"},
+		},
+		Dlls: []DllOverride{
+			{Dll: "d3d9", Order: "n,b"},
+			{Dll: "mscoree", Order: ""},
+		},
+		Isolation: true,
+		Isolated: []IsolatedLink{
+			{Path: "dosdevices/z:", Target: "/"},
+			{Path: "drive_c/users/u/My Documents", Target: "/home/u/Documents"},
+		},
+	}
+
+	var writeErr = written.write(prefixDir)
+	var gotten, readErr = readPrefixConfig(prefixDir)
+
+	if fmt.Sprint(written) != fmt.Sprint(gotten) {
+		errorExpGot(t, written, gotten, false)
+	}
+
+	if writeErr != nil || readErr != nil {
+		errorExpGot(t, nil, []error{writeErr, readErr}, true)
+	}
+}
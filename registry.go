@@ -0,0 +1,499 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// a file in the text format wine keeps its registry in (system.reg, user.reg),
+// lines are kept as read so that what is not changed is written back the same
+type RegFile struct {
+	Header []string
+	Keys   []RegKey
+}
+
+// a key in a registry file, with the line it starts with
+// and the lines of options and values under it
+type RegKey struct {
+	Path  string
+	Line  string
+	Lines []string
+}
+
+// a value of a registry key, data is kept as written in the file
+type RegValue struct {
+	Name string
+	Data string
+}
+
+// the keys programs register themselves under to be uninstallable
+var uninstallKeys = []string{
+	`Software\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`Software\Wow6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
+}
+
+// read a wine registry file into keys and values
+func readRegFile(fileName string) (ret RegFile, retErr error) {
+	// read the file
+	data, retErr := ioutil.ReadFile(fileName)
+	if retErr != nil {
+		return
+	}
+
+	var lines = strings.Split(string(data), "\n")
+
+	// the key lines are being added to, nil until the first key
+	var current *RegKey
+
+	for i, line := range lines {
+		switch {
+		// start of a new key
+		case strings.HasPrefix(line, "["):
+			var closing = strings.LastIndex(line, "]")
+			if closing == -1 {
+				return ret, fmt.Errorf("%s: line %d: unclosed key", fileName, i+1)
+			}
+
+			ret.Keys = append(ret.Keys, RegKey{
+				Path: regUnescape(line[1:closing]),
+				Line: line,
+			})
+			current = &ret.Keys[len(ret.Keys)-1]
+
+		// everything before the first key
+		case current == nil:
+			ret.Header = append(ret.Header, line)
+
+		default:
+			current.Lines = append(current.Lines, line)
+		}
+	}
+
+	// check the values can be read
+	for _, k := range ret.Keys {
+		if _, valuesErr := k.values(); valuesErr != nil {
+			return ret, fmt.Errorf("%s: %s", fileName, valuesErr.Error())
+		}
+	}
+
+	return
+}
+
+// write a registry back into a file
+func (f RegFile) write(fileName string) error {
+	var allLines = append([]string{}, f.Header...)
+	for _, k := range f.Keys {
+		allLines = append(allLines, k.Line)
+		allLines = append(allLines, k.Lines...)
+	}
+
+	return ioutil.WriteFile(fileName, []byte(strings.Join(allLines, "\n")), os.FileMode(0644))
+}
+
+// get the values of a key along with the index of the line
+// each starts at and the number of lines it spans
+func (k RegKey) valueLines() (retList []RegValue, starts []int, spans []int, retErr error) {
+	for i := 0; i < len(k.Lines); i++ {
+		var line = k.Lines[i]
+		if !strings.HasPrefix(line, `"`) && !strings.HasPrefix(line, "@") {
+			continue
+		}
+
+		// data ending with a backslash continues on the next line
+		var start = i
+		for strings.HasSuffix(line, `\`) && i+1 < len(k.Lines) {
+			i++
+			line += "\n" + k.Lines[i]
+		}
+
+		var value, parseErr = regParseValue(line)
+		if parseErr != nil {
+			return nil, nil, nil, fmt.Errorf("key %s: %s", k.Path, parseErr.Error())
+		}
+
+		retList = append(retList, value)
+		starts = append(starts, start)
+		spans = append(spans, i-start+1)
+	}
+
+	return
+}
+
+// get the values of a key
+func (k RegKey) values() ([]RegValue, error) {
+	var retList, _, _, retErr = k.valueLines()
+	return retList, retErr
+}
+
+// get the data of a value as written in the file, found is
+// false if there is no such value, the default one is named ""
+func (k RegKey) value(name string) (data string, found bool) {
+	var values, _ = k.values()
+	for _, v := range values {
+		if strings.EqualFold(v.Name, name) {
+			return v.Data, true
+		}
+	}
+	return "", false
+}
+
+// set the data of a value, replacing it or adding it
+// after the other values of the key
+func (k *RegKey) setValue(name string, data string) {
+	var values, starts, spans, _ = k.valueLines()
+
+	for i, v := range values {
+		// keep the name as it was written
+		if strings.EqualFold(v.Name, name) {
+			var newLines = append([]string{}, k.Lines[:starts[i]]...)
+			newLines = append(newLines, regValueLine(v.Name, data))
+			k.Lines = append(newLines, k.Lines[starts[i]+spans[i]:]...)
+			return
+		}
+	}
+
+	// add after the last line that is not empty
+	var at = len(k.Lines)
+	for at > 0 && strings.TrimSpace(k.Lines[at-1]) == "" {
+		at--
+	}
+
+	var newLines = append([]string{}, k.Lines[:at]...)
+	newLines = append(newLines, regValueLine(name, data))
+	k.Lines = append(newLines, k.Lines[at:]...)
+}
+
+// remove a value from a key, returning false if it was not there
+func (k *RegKey) deleteValue(name string) bool {
+	var values, starts, spans, _ = k.valueLines()
+
+	for i, v := range values {
+		if strings.EqualFold(v.Name, name) {
+			k.Lines = append(k.Lines[:starts[i]], k.Lines[starts[i]+spans[i]:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// make up the line of a value, the default one being named ""
+func regValueLine(name string, data string) string {
+	if name == "" {
+		return "@=" + data
+	}
+	return `"` + regEscape(name) + `"=` + data
+}
+
+// find a key by its path making it if it does not exist
+func (f *RegFile) makeKey(keyPath string) *RegKey {
+	if found, ok := f.key(keyPath); ok {
+		return found
+	}
+
+	// keys are separated by an empty line
+	var lastLines = &f.Header
+	if len(f.Keys) > 0 {
+		lastLines = &f.Keys[len(f.Keys)-1].Lines
+	}
+	if len(*lastLines) == 0 || (*lastLines)[len(*lastLines)-1] != "" {
+		*lastLines = append(*lastLines, "")
+	}
+
+	f.Keys = append(f.Keys, RegKey{
+		Path:  keyPath,
+		Line:  fmt.Sprintf("[%s] %d", regEscape(keyPath), time.Now().Unix()),
+		Lines: []string{""},
+	})
+
+	return &f.Keys[len(f.Keys)-1]
+}
+
+// split a value line into the name and the data
+func regParseValue(line string) (ret RegValue, retErr error) {
+	// default value has no name
+	if strings.HasPrefix(line, "@=") {
+		ret.Data = line[2:]
+		return
+	}
+
+	// find the quote closing the name skipping escaped ones
+	var closing = -1
+	for i := 1; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == '"' {
+			closing = i
+			break
+		}
+	}
+
+	if closing == -1 || closing+1 >= len(line) || line[closing+1] != '=' {
+		return ret, fmt.Errorf("malformed value %q", line)
+	}
+
+	ret.Name = regUnescape(line[1:closing])
+	ret.Data = line[closing+2:]
+	return
+}
+
+// undo the escaping wine does in key names and strings
+func regUnescape(str string) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' || i+1 == len(str) {
+			builder.WriteByte(str[i])
+			continue
+		}
+
+		i++
+		switch str[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case '0':
+			builder.WriteByte(0)
+		case 'x':
+			// up to four hex digits make a character
+			var end = i + 1
+			for end < len(str) && end < i+5 && strings.ContainsRune("0123456789abcdefABCDEF", rune(str[end])) {
+				end++
+			}
+			var code rune
+			fmt.Sscanf(str[i+1:end], "%x", &code)
+			builder.WriteRune(code)
+			i = end - 1
+		default:
+			builder.WriteByte(str[i])
+		}
+	}
+
+	return builder.String()
+}
+
+// escape key names and strings the way wine does
+func regEscape(str string) string {
+	var builder strings.Builder
+
+	for _, char := range str {
+		switch {
+		case char == '\\' || char == '"':
+			builder.WriteRune('\\')
+			builder.WriteRune(char)
+		case char == '\n':
+			builder.WriteString(`\n`)
+		case char == '\r':
+			builder.WriteString(`\r`)
+		case char == '\t':
+			builder.WriteString(`\t`)
+		case char < ' ' || char > '~':
+			builder.WriteString(fmt.Sprintf(`\x%04x`, char))
+		default:
+			builder.WriteRune(char)
+		}
+	}
+
+	return builder.String()
+}
+
+// find a key by its path, case is ignored like windows does
+func (f RegFile) key(keyPath string) (*RegKey, bool) {
+	for i := range f.Keys {
+		if strings.EqualFold(f.Keys[i].Path, keyPath) {
+			return &f.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// get the keys directly under a key
+func (f RegFile) subKeys(keyPath string) (retList []RegKey) {
+	var parent = strings.ToLower(keyPath) + `\`
+
+	for _, k := range f.Keys {
+		var lowerPath = strings.ToLower(k.Path)
+		if !strings.HasPrefix(lowerPath, parent) {
+			continue
+		}
+		// leave out keys nested deeper
+		if strings.Contains(lowerPath[len(parent):], `\`) {
+			continue
+		}
+		retList = append(retList, k)
+	}
+
+	return
+}
+
+// get a string value of a key, found is false if it
+// does not exist or is not a string
+func (k RegKey) stringValue(name string) (ret string, found bool) {
+	var data, exists = k.value(name)
+	if !exists {
+		return "", false
+	}
+
+	// expandable strings are stored with a type prefix
+	data = strings.TrimPrefix(data, "str(2):")
+
+	if len(data) < 2 || !strings.HasPrefix(data, `"`) || !strings.HasSuffix(data, `"`) {
+		return "", false
+	}
+	return regUnescape(data[1 : len(data)-1]), true
+}
+
+// read the programs installed in a prefix from its registry
+// and get a list of exes for them bound to the prefix
+func importFromRegistry(prefixDir string, prefixName string) (retList []Exe, retErr []error) {
+	for _, regName := range []string{"system.reg", "user.reg"} {
+		var regFile, readErr = readRegFile(path.Join(prefixDir, regName))
+		if readErr != nil {
+			// a prefix might not have a user registry yet
+			if os.IsNotExist(readErr) && regName == "user.reg" {
+				continue
+			}
+			retErr = append(retErr, readErr)
+			continue
+		}
+
+		for _, uninstallKey := range uninstallKeys {
+			for _, programKey := range regFile.subKeys(uninstallKey) {
+				var found, ok = exeFromUninstallKey(prefixDir, programKey)
+				if !ok {
+					continue
+				}
+
+				found.Prefix = prefixName
+				retList = mergeExeLists(retList, []Exe{found})
+			}
+		}
+	}
+
+	return
+}
+
+// make an exe out of the uninstall information of a program
+// ok is false if no exe could be found for it
+func exeFromUninstallKey(prefixDir string, programKey RegKey) (ret Exe, ok bool) {
+	var (
+		displayName, _     = programKey.stringValue("DisplayName")
+		installLocation, _ = programKey.stringValue("InstallLocation")
+		displayIcon, _     = programKey.stringValue("DisplayIcon")
+		uninstallString, _ = programKey.stringValue("UninstallString")
+	)
+
+	// programs with no name are not meant to be shown
+	if displayName == "" {
+		return
+	}
+
+	// the icon is a path optionally followed by an index
+	var iconPath = strings.Trim(displayIcon, `"`)
+	if comma := strings.LastIndex(iconPath, ","); comma != -1 {
+		iconPath = iconPath[:comma]
+	}
+	iconPath, _ = unixFromWindows(prefixDir, iconPath)
+
+	// the uninstaller is never the exe looked for
+	var uninstallerName = strings.ToLower(path.Base(
+		strings.ReplaceAll(commandExe(uninstallString), `\`, "/"),
+	))
+	var isUninstaller = func(exePath string) bool {
+		var base = strings.ToLower(path.Base(exePath))
+		return base == uninstallerName || strings.HasPrefix(base, "unins")
+	}
+
+	ret.Name = displayName
+
+	// use the icon if it points to an exe
+	if strings.EqualFold(filepath.Ext(iconPath), ".exe") && !isUninstaller(iconPath) {
+		if _, statErr := os.Stat(iconPath); statErr == nil {
+			ret.Path = iconPath
+			ret.Icon = iconPath
+			return ret, true
+		}
+	}
+
+	// otherwise look for one in the install location
+	if installLocation == "" {
+		return
+	}
+
+	var locationPath, locationErr = unixFromWindows(prefixDir, installLocation)
+	if locationErr != nil {
+		return
+	}
+
+	var scanned, _ = importFromScan(locationPath)
+	var candidates []Exe
+	for _, element := range scanned {
+		if !isUninstaller(element.Path) {
+			candidates = append(candidates, element)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	ret.Path = pickExe(displayName, candidates).Path
+	if _, statErr := os.Stat(iconPath); statErr == nil {
+		ret.Icon = iconPath
+	}
+
+	return ret, true
+}
+
+// get the program out of a windows command line
+// which is either quoted or ends at its extension
+func commandExe(commandLine string) string {
+	commandLine = strings.TrimSpace(commandLine)
+
+	if strings.HasPrefix(commandLine, `"`) {
+		var closing = strings.Index(commandLine[1:], `"`)
+		if closing == -1 {
+			return commandLine[1:]
+		}
+		return commandLine[1 : closing+1]
+	}
+
+	if extIndex := strings.Index(strings.ToLower(commandLine), ".exe"); extIndex != -1 {
+		return commandLine[:extIndex+len(".exe")]
+	}
+
+	return commandLine
+}
+
+// choose the exe most likely to be the program out of several,
+// the one named like the program or else the least nested one
+func pickExe(displayName string, candidates []Exe) (ret Exe) {
+	var squash = func(str string) string {
+		return strings.ToLower(strings.ReplaceAll(str, " ", ""))
+	}
+
+	for _, element := range candidates {
+		if strings.Contains(squash(displayName), squash(element.Name)) {
+			return element
+		}
+	}
+
+	ret = candidates[0]
+	for _, element := range candidates[1:] {
+		if strings.Count(element.Path, "/") < strings.Count(ret.Path, "/") {
+			ret = element
+		}
+	}
+
+	return
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// registry of a prefix with two programs installed, one of them
+// with an icon pointing to its exe and one only with a location
+const testSystemReg = `WINE REGISTRY Version 2
+;; All keys relative to \\Machine
+
+#arch=win64
+
+[Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\Flap] 1700000000
+#time=1da0b0c0d0e0f00
+"DisplayIcon"="C:\\Games\\Flap\\flap.exe,0"
+"DisplayName"="Flap Deluxe"
+"UninstallString"="\"C:\\Games\\Flap\\unins000.exe\""
+
+[Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\Paint] 1700000000
+"DisplayName"="Paint Shop"
+"InstallLocation"="C:\\Paint Shop"
+"UninstallString"="C:\\Paint Shop\\uninstall.exe /S"
+
+[Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\Paint\\Extra] 1700000000
+"DisplayName"="Nested"
+
+[Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\Mono] 1700000000
+"DisplayName"="Wine Mono Runtime"
+"Size"=dword:00000010
+"Blob"=hex:00,01,02,\
+  03,04
+`
+
+func TestReadRegFile(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var testFileName = inTestDir("system.reg")
+
+	var testTable = []struct {
+		Description    string
+		ExpectedKeys   int
+		ExpectedHeader int
+		ExpectedErr    error
+
+		ParamContent string
+	}{
+		{
+			Description:    "read a regular registry file",
+			ExpectedKeys:   4,
+			ExpectedHeader: 5,
+			ExpectedErr:    nil,
+
+			ParamContent: testSystemReg,
+		},
+		{
+			Description:    "read a registry file with an unclosed key",
+			ExpectedKeys:   0,
+			ExpectedHeader: 2,
+			ExpectedErr:    fmt.Errorf("%s: line 3: unclosed key", testFileName),
+
+			ParamContent: "WINE REGISTRY Version 2\n\n[Software\\\\Wine 1700000000\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.WriteFile(testFileName, []byte(testCase.ParamContent), 0644)
+			defer os.Remove(testFileName)
+
+			var gotten, gottenErr = readRegFile(testFileName)
+
+			if testCase.ExpectedKeys != len(gotten.Keys) {
+				errorExpGot(t, testCase.ExpectedKeys, len(gotten.Keys), false)
+			}
+
+			if testCase.ExpectedHeader != len(gotten.Header) {
+				errorExpGot(t, testCase.ExpectedHeader, len(gotten.Header), false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestRegFileWrite(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var testFileName = inTestDir("system.reg")
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamContent string
+		ParamChange  func(f *RegFile)
+	}{
+		{
+			Description: "write back a file without changes",
+			Expected:    testSystemReg,
+
+			ParamContent: testSystemReg,
+			ParamChange:  func(f *RegFile) {},
+		},
+		{
+			Description: "replace a value spanning lines and remove another",
+			Expected: strings.Replace(
+				strings.Replace(testSystemReg, "\"Blob\"=hex:00,01,02,\\\n  03,04\n", "\"Blob\"=dword:00000001\n", 1),
+				"\"DisplayName\"=\"Nested\"\n", "", 1,
+			),
+
+			ParamContent: testSystemReg,
+			ParamChange: func(f *RegFile) {
+				var mono, _ = f.key(`Software\Microsoft\Windows\CurrentVersion\Uninstall\Mono`)
+				mono.setValue("blob", "dword:00000001")
+				var nested, _ = f.key(`Software\Microsoft\Windows\CurrentVersion\Uninstall\Paint\Extra`)
+				nested.deleteValue("DisplayName")
+			},
+		},
+		{
+			Description: "add a value to a key and a new key",
+			Expected: "WINE REGISTRY Version 2\n\n[Software\\\\Wine] 1700000000\n" +
+				"#time=1d\n\"Version\"=\"win7\"\n\"Path\"=\"C:\\\\x\"\n\n" +
+				"[Software\\\\Wine\\\\Direct3D] 0\n@=\"a \\\"b\\\"\"\n",
+
+			ParamContent: "WINE REGISTRY Version 2\n\n[Software\\\\Wine] 1700000000\n#time=1d\n\"Version\"=\"win7\"\n",
+			ParamChange: func(f *RegFile) {
+				var wine, _ = f.key(`Software\Wine`)
+				wine.setValue("Path", regData(`C:\x`))
+				var d3d = f.makeKey(`Software\Wine\Direct3D`)
+				d3d.Line = `[Software\\Wine\\Direct3D] 0`
+				d3d.setValue("", regData(`a "b"`))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.WriteFile(testFileName, []byte(testCase.ParamContent), 0644)
+			defer os.Remove(testFileName)
+
+			var regFile, _ = readRegFile(testFileName)
+			testCase.ParamChange(&regFile)
+			var writeErr = regFile.write(testFileName)
+
+			var data, _ = os.ReadFile(testFileName)
+			var gotten = string(data)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if writeErr != nil {
+				errorExpGot(t, nil, writeErr, true)
+			}
+		})
+	}
+}
+
+func TestRegKeyStringValue(t *testing.T) {
+	var testKey = RegKey{
+		Path: `Software\Test`,
+		Lines: []string{
+			`"Plain"="C:\\Games\\flap.exe"`,
+			`"Expand"=str(2):"%ProgramFiles%\\Flap"`,
+			`"Quoted"="say \"hi\"\x263a"`,
+			`"Number"=dword:00000010`,
+		},
+	}
+
+	var testTable = []struct {
+		Description   string
+		Expected      string
+		ExpectedFound bool
+
+		ParamName string
+	}{
+		{
+			Description:   "a plain string with escaped backslashes",
+			Expected:      `C:\Games\flap.exe`,
+			ExpectedFound: true,
+			ParamName:     "plain",
+		},
+		{
+			Description:   "an expandable string",
+			Expected:      `%ProgramFiles%\Flap`,
+			ExpectedFound: true,
+			ParamName:     "Expand",
+		},
+		{
+			Description:   "a string with escaped quotes and characters",
+			Expected:      "say \"hi\"\u263a",
+			ExpectedFound: true,
+			ParamName:     "Quoted",
+		},
+		{
+			Description:   "a value that is not a string",
+			Expected:      "",
+			ExpectedFound: false,
+			ParamName:     "Number",
+		},
+		{
+			Description:   "a value that does not exist",
+			Expected:      "",
+			ExpectedFound: false,
+			ParamName:     "Missing",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenFound = testKey.stringValue(testCase.ParamName)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if testCase.ExpectedFound != gottenFound {
+				errorExpGot(t, testCase.ExpectedFound, gottenFound, false)
+			}
+		})
+	}
+}
+
+func TestImportFromRegistry(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+
+	var testTable = []struct {
+		Description  string
+		Expected     []Exe
+		ExpectedErrs []error
+
+		ParamRegistry string
+		ParamDirs     []string
+		ParamFiles    []string
+	}{
+		{
+			Description: "programs with an icon exe and with an install location",
+			Expected: []Exe{
+				{
+					Name:   "Flap Deluxe",
+					Path:   inTestDir("prefix/drive_c/Games/Flap/flap.exe"),
+					Prefix: "games",
+					Icon:   inTestDir("prefix/drive_c/Games/Flap/flap.exe"),
+				},
+				{
+					Name:   "Paint Shop",
+					Path:   inTestDir("prefix/drive_c/Paint Shop/paint.exe"),
+					Prefix: "games",
+				},
+			},
+			ExpectedErrs: []error{},
+
+			ParamRegistry: testSystemReg,
+			ParamDirs: []string{
+				inTestDir("prefix/drive_c/Games/Flap"),
+				inTestDir("prefix/drive_c/Paint Shop/bin"),
+			},
+			ParamFiles: []string{
+				inTestDir("prefix/drive_c/Games/Flap/flap.exe"),
+				inTestDir("prefix/drive_c/Games/Flap/unins000.exe"),
+				inTestDir("prefix/drive_c/Paint Shop/uninstall.exe"),
+				inTestDir("prefix/drive_c/Paint Shop/bin/helper.exe"),
+				inTestDir("prefix/drive_c/Paint Shop/paint.exe"),
+			},
+		},
+		{
+			Description:  "programs whose exes are missing",
+			Expected:     []Exe{},
+			ExpectedErrs: []error{},
+
+			ParamRegistry: testSystemReg,
+			ParamDirs: []string{
+				inTestDir("prefix/drive_c"),
+			},
+			ParamFiles: []string{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			for _, dirToMake := range testCase.ParamDirs {
+				os.MkdirAll(dirToMake, 0755)
+			}
+			defer os.RemoveAll(prefixDir)
+
+			for _, fileToMake := range testCase.ParamFiles {
+				os.WriteFile(fileToMake, []byte{}, 0755)
+			}
+
+			// link the c drive like wine does
+			os.MkdirAll(inTestDir("prefix/dosdevices"), 0755)
+			os.Symlink("../drive_c", inTestDir("prefix/dosdevices/c:"))
+			os.WriteFile(inTestDir("prefix/system.reg"), []byte(testCase.ParamRegistry), 0644)
+
+			var gotten, gottenErrs = importFromRegistry(prefixDir, "games")
+
+			if equalExeList(t, testCase.Expected, gotten) == false {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, testCase.ExpectedErrs, gottenErrs) == false {
+				errorExpGot(t, testCase.ExpectedErrs, gottenErrs, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// a change to a registry value wanted before running an entry,
+// the key starts with its hive and data is as written in registry
+// files or "-" to remove the value
+type RegTweak struct {
+	Key  string
+	Name string
+	Data string
+}
+
+// read a registry tweak as written in the list file,
+// the key, value name (@ for the default one) and data split by bars
+func parseRegTweak(value string) (ret RegTweak, retErr error) {
+	var parts = strings.SplitN(value, "|", 3)
+	if len(parts) != 3 {
+		return ret, fmt.Errorf("registry %q: needs a key, a name and data", value)
+	}
+
+	ret.Key = strings.TrimSpace(parts[0])
+	ret.Name = strings.TrimSpace(parts[1])
+	ret.Data = regData(strings.TrimSpace(parts[2]))
+
+	if ret.Name == "@" {
+		ret.Name = ""
+	}
+
+	if _, _, hiveErr := regFileOfKey("", ret.Key); hiveErr != nil {
+		return ret, fmt.Errorf("registry %q: %s", value, hiveErr.Error())
+	}
+
+	return
+}
+
+// write a registry tweak the way it is read
+func (t RegTweak) String() string {
+	var name = t.Name
+	if name == "" {
+		name = "@"
+	}
+	return t.Key + " | " + name + " | " + t.Data
+}
+
+// get the registry file of the hive a key is in
+// and the path of the key inside that file
+func regFileOfKey(prefixDir string, key string) (fileName string, keyPath string, retErr error) {
+	var parts = strings.SplitN(key, `\`, 2)
+	if len(parts) == 2 {
+		keyPath = parts[1]
+	}
+
+	switch strings.ToUpper(parts[0]) {
+	case "HKCU", "HKEY_CURRENT_USER":
+		fileName = path.Join(prefixDir, "user.reg")
+	case "HKLM", "HKEY_LOCAL_MACHINE":
+		fileName = path.Join(prefixDir, "system.reg")
+	default:
+		return "", "", fmt.Errorf("key %s: hive %s is not HKCU or HKLM", key, parts[0])
+	}
+
+	return
+}
+
+// get the lines wine starts a registry file with
+func regFileHeader(fileName string) []string {
+	var relativeTo = `\\Machine`
+	if path.Base(fileName) == "user.reg" {
+		relativeTo = `\\User\\S-1-5-21-0-0-0-1000`
+	}
+	return []string{"WINE REGISTRY Version 2", ";; All keys relative to " + relativeTo}
+}
+
+// turn data into the way registry files have it, data already
+// written that way is kept and everything else is a string
+func regData(value string) string {
+	for _, typed := range []string{`"`, "dword:", "hex:", "hex(", "str(", "-"} {
+		if strings.HasPrefix(value, typed) {
+			return value
+		}
+	}
+	return `"` + regEscape(value) + `"`
+}
+
+// change registry values of a prefix, returning tweaks that
+// put back what was there before when applied in turn
+func applyRegTweaks(prefixDir string, tweaks []RegTweak) (previous []RegTweak, retErr error) {
+	// files are read once and written after all changes
+	var files = make(map[string]*RegFile)
+	var fileOrder []string
+
+	for _, tweak := range tweaks {
+		var fileName, keyPath, hiveErr = regFileOfKey(prefixDir, tweak.Key)
+		if hiveErr != nil {
+			return nil, hiveErr
+		}
+
+		if _, loaded := files[fileName]; !loaded {
+			var regFile, readErr = readRegFile(fileName)
+			if os.IsNotExist(readErr) {
+				// a fresh prefix has no registry until wine first
+				// runs in it, wine merges its defaults into this one
+				regFile, readErr = RegFile{Header: regFileHeader(fileName)}, os.MkdirAll(prefixDir, 0755)
+			}
+			if readErr != nil {
+				return nil, readErr
+			}
+			files[fileName] = &regFile
+			fileOrder = append(fileOrder, fileName)
+		}
+		var regFile = files[fileName]
+
+		// remember what is there now, "-" for nothing
+		var oldData = "-"
+		var k, keyFound = regFile.key(keyPath)
+		if keyFound {
+			if data, valueFound := k.value(tweak.Name); valueFound {
+				oldData = data
+			}
+		}
+		previous = append([]RegTweak{{Key: tweak.Key, Name: tweak.Name, Data: oldData}}, previous...)
+
+		if tweak.Data == "-" {
+			if keyFound {
+				k.deleteValue(tweak.Name)
+			}
+			continue
+		}
+
+		regFile.makeKey(keyPath).setValue(tweak.Name, tweak.Data)
+	}
+
+	for _, fileName := range fileOrder {
+		if writeErr := files[fileName].write(fileName); writeErr != nil {
+			return previous, writeErr
+		}
+	}
+
+	return
+}
+
+// get the data of a registry value in a prefix as text,
+// strings are given as they are and other data as written
+func readRegValue(prefixDir string, key string, name string) (ret string, retErr error) {
+	var fileName, keyPath, hiveErr = regFileOfKey(prefixDir, key)
+	if hiveErr != nil {
+		return "", hiveErr
+	}
+
+	var regFile, readErr = readRegFile(fileName)
+	if readErr != nil {
+		return "", readErr
+	}
+
+	var k, keyFound = regFile.key(keyPath)
+	if !keyFound {
+		return "", fmt.Errorf("key %s: not found", key)
+	}
+
+	if str, isString := k.stringValue(name); isString {
+		return str, nil
+	}
+
+	if data, valueFound := k.value(name); valueFound {
+		return data, nil
+	}
+
+	return "", fmt.Errorf("key %s: no value named %q", key, name)
+}
+
+// get all values of a registry key in a prefix as lines
+// the way they are written in registry files
+func displayRegKey(prefixDir string, key string) (ret string, retErr error) {
+	var fileName, keyPath, hiveErr = regFileOfKey(prefixDir, key)
+	if hiveErr != nil {
+		return "", hiveErr
+	}
+
+	var regFile, readErr = readRegFile(fileName)
+	if readErr != nil {
+		return "", readErr
+	}
+
+	var k, keyFound = regFile.key(keyPath)
+	if !keyFound {
+		return "", fmt.Errorf("key %s: not found", key)
+	}
+
+	var values, _ = k.values()
+	for _, v := range values {
+		ret += regValueLine(v.Name, v.Data) + "\n"
+	}
+
+	return
+}
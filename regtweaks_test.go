@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseRegTweak(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    RegTweak
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "a plain string for a named value",
+			Expected:    RegTweak{Key: `HKCU\Software\Wine`, Name: "Version", Data: `"winxp"`},
+			ExpectedErr: nil,
+			ParamValue:  `HKCU\Software\Wine | Version | winxp`,
+		},
+		{
+			Description: "a dword for the default value",
+			Expected:    RegTweak{Key: `HKEY_LOCAL_MACHINE\Software\Test`, Name: "", Data: "dword:00000001"},
+			ExpectedErr: nil,
+			ParamValue:  `HKEY_LOCAL_MACHINE\Software\Test|@|dword:00000001`,
+		},
+		{
+			Description: "a key in an unknown hive",
+			Expected:    RegTweak{Key: `HKU\Software`, Name: "x", Data: "-"},
+			ExpectedErr: fmt.Errorf(`registry "HKU\\Software | x | -": key HKU\Software: hive HKU is not HKCU or HKLM`),
+			ParamValue:  `HKU\Software | x | -`,
+		},
+		{
+			Description: "no data",
+			Expected:    RegTweak{},
+			ExpectedErr: fmt.Errorf(`registry "HKCU\\Software | x": needs a key, a name and data`),
+			ParamValue:  `HKCU\Software | x`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseRegTweak(testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestApplyRegTweaks(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	os.MkdirAll(prefixDir, 0755)
+
+	var userReg = "WINE REGISTRY Version 2\n;; All keys relative to \\\\User\\\\S-1-5-21-0-0-0-1000\n\n" +
+		"#arch=win64\n\n[Software\\\\Wine] 1700000000\n\"Version\"=\"win10\"\n\"Keep\"=dword:00000001\n"
+	var systemReg = "WINE REGISTRY Version 2\n;; All keys relative to \\\\Machine\n\n#arch=win64\n"
+
+	os.WriteFile(prefixDir+"/user.reg", []byte(userReg), 0644)
+	os.WriteFile(prefixDir+"/system.reg", []byte(systemReg), 0644)
+
+	var tweaks = []RegTweak{
+		{Key: `HKCU\Software\Wine`, Name: "Version", Data: `"winxp"`},
+		{Key: `HKCU\Software\Wine`, Name: "Keep", Data: "-"},
+		{Key: `HKLM\Software\Test`, Name: "", Data: `"on"`},
+	}
+
+	var previous, applyErr = applyRegTweaks(prefixDir, tweaks)
+	if applyErr != nil {
+		errorExpGot(t, nil, applyErr, true)
+	}
+
+	// values read back as they were set
+	var testTable = []struct {
+		Key      string
+		Name     string
+		Expected string
+	}{
+		{`HKCU\Software\Wine`, "Version", "winxp"},
+		{`HKCU\Software\Wine`, "Keep", ""},
+		{`HKLM\Software\Test`, "", "on"},
+	}
+
+	for _, testCase := range testTable {
+		var gotten, _ = readRegValue(prefixDir, testCase.Key, testCase.Name)
+		if testCase.Expected != gotten {
+			errorExpGot(t, testCase.Expected, gotten, false)
+		}
+	}
+
+	// putting back what was there gives the same user registry
+	applyRegTweaks(prefixDir, previous)
+
+	var data, _ = os.ReadFile(prefixDir + "/user.reg")
+	var expected = "WINE REGISTRY Version 2\n;; All keys relative to \\\\User\\\\S-1-5-21-0-0-0-1000\n\n" +
+		"#arch=win64\n\n[Software\\\\Wine] 1700000000\n\"Version\"=\"win10\"\n\"Keep\"=dword:00000001\n"
+
+	if string(data) != expected {
+		errorExpGot(t, expected, string(data), false)
+	}
+}
+
+func TestApplyRegTweaksFreshPrefix(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// wine has not run in the prefix yet, so it has no registry
+	var prefixDir = inTestDir("fresh")
+
+	var tweaks = []RegTweak{
+		{Key: `HKCU\Software\Wine`, Name: "Version", Data: `"winxp"`},
+		{Key: `HKLM\Software\Test`, Name: "", Data: `"on"`},
+	}
+
+	var previous, applyErr = applyRegTweaks(prefixDir, tweaks)
+	if applyErr != nil {
+		errorExpGot(t, nil, applyErr, true)
+	}
+
+	var expectedPrevious = []RegTweak{
+		{Key: `HKLM\Software\Test`, Name: "", Data: "-"},
+		{Key: `HKCU\Software\Wine`, Name: "Version", Data: "-"},
+	}
+	if fmt.Sprint(expectedPrevious) != fmt.Sprint(previous) {
+		errorExpGot(t, expectedPrevious, previous, false)
+	}
+
+	var testTable = []struct {
+		Description string
+		Key         string
+		Name        string
+		Expected    string
+	}{
+		{"user registry", `HKCU\Software\Wine`, "Version", "winxp"},
+		{"system registry", `HKLM\Software\Test`, "", "on"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var fileName, _, _ = regFileOfKey(prefixDir, testCase.Key)
+			var data, _ = os.ReadFile(fileName)
+			var header = strings.Join(regFileHeader(fileName), "\n") + "\n"
+			if !strings.HasPrefix(string(data), header) {
+				errorExpGot(t, header, string(data), false)
+			}
+
+			var gotten, readErr = readRegValue(prefixDir, testCase.Key, testCase.Name)
+			if readErr != nil {
+				errorExpGot(t, nil, readErr, true)
+			}
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
@@ -1,23 +1,46 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 type Runner struct {
 	Program     string
 	ProgramArgs string
 	DefaultDir  string
+	PrefixDir   string
+	Winetricks  string
+	StateDir    string
+	Wait        string
+	Limit       string
+	Quota       string
+	Single      string
+	Verify      string
+	Hooks       []Hook
+	LogKeep     int
+	LogSize     int64
+	SaveKeep    int
 	List        []Exe
+	Dlls        []DllOverride
 
-	ConfigFile string
-	ListFile   string
+	DebugPresets map[string]string
+
+	RestoreDrives   bool
+	RestoreRegistry bool
+
+	ConfigFile    string
+	ListFile      string
+	DiagnosesFile string
 }
 
 // see if there is a configuration stored in configuration dir
@@ -33,9 +56,15 @@ func runnerInitMake() (ret Runner) {
 	ret.ProgramArgs = ""
 	var homedir, _ = os.UserHomeDir()
 	ret.DefaultDir = homedir
+	ret.PrefixDir = path.Join(homedir, ".local", "share", "wineprefixes")
+	ret.StateDir = path.Join(homedir, ".local", "state", "winela")
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		ret.StateDir = path.Join(stateHome, "winela")
+	}
 	ret.List = []Exe{}
 	ret.ConfigFile = path.Join(progDir, "winelarc")
 	ret.ListFile = path.Join(progDir, "wineladb")
+	ret.DiagnosesFile = path.Join(progDir, "diagnoses")
 
 	// try import and go from there
 
@@ -88,7 +117,7 @@ func (r *Runner) runnerReadConfig() {
 
 	for _, line := range lines {
 		// split into pairs
-		var pair = strings.Split(line, "=")
+		var pair = strings.SplitN(line, "=", 2)
 		if len(pair) != 2 {
 			continue
 		}
@@ -105,6 +134,57 @@ func (r *Runner) runnerReadConfig() {
 			r.ProgramArgs = right
 		case "DefaultDir":
 			r.DefaultDir = right
+		case "PrefixDir":
+			r.PrefixDir = right
+		case "Winetricks":
+			r.Winetricks = right
+		case "StateDir":
+			r.StateDir = right
+		case "LogKeep":
+			r.LogKeep, _ = strconv.Atoi(right)
+		case "LogSize":
+			r.LogSize, _ = parseSize(right)
+		case "SaveKeep":
+			r.SaveKeep, _ = strconv.Atoi(right)
+		case "Wait":
+			if isWaitStrategy(right) {
+				r.Wait = right
+			}
+		case "Limit":
+			if isLimit(right) {
+				r.Limit = right
+			}
+		case "Quota":
+			if isLimit(right) {
+				r.Quota = right
+			}
+		case "Single":
+			if isSinglePolicy(right) {
+				r.Single = right
+			}
+		case "PreLaunch", "PostLaunch", "PostExit", "LimitWarning":
+			r.Hooks = append(r.Hooks, Hook{When: left, Command: right})
+		case "Verify":
+			if isVerifyPolicy(right) {
+				r.Verify = right
+			}
+		case "RestoreDrives":
+			r.RestoreDrives, _ = strconv.ParseBool(right)
+		case "RestoreRegistry":
+			r.RestoreRegistry, _ = strconv.ParseBool(right)
+		case "Dll":
+			if override, parseErr := parseDllOverride(right); parseErr == nil {
+				r.Dlls = setDllOverride(r.Dlls, override)
+			}
+		case "DebugPreset":
+			// the name of the preset and its channels
+			var fields = strings.Fields(right)
+			if len(fields) == 2 && isDebugChannels(fields[1]) {
+				if r.DebugPresets == nil {
+					r.DebugPresets = make(map[string]string)
+				}
+				r.DebugPresets[fields[0]] = fields[1]
+			}
 		}
 	}
 }
@@ -124,6 +204,49 @@ func (r *Runner) RunnerWriteConfig() {
 		strList += fmt.Sprintf("%v = %v\n", leftList[i], rightList[i])
 	}
 
+	// optional settings are only written when set
+	var optLeftList = []string{
+		"PrefixDir", "Winetricks", "StateDir", "Wait", "Limit", "Quota", "Single", "Verify", "RestoreDrives", "RestoreRegistry",
+	}
+	var optRightList = []string{
+		r.PrefixDir, r.Winetricks, r.StateDir, r.Wait, r.Limit, r.Quota, r.Single, r.Verify, formatOptBool(r.RestoreDrives), formatOptBool(r.RestoreRegistry),
+	}
+
+	for i := range optLeftList {
+		if optRightList[i] == "" {
+			continue
+		}
+		strList += fmt.Sprintf("%v = %v\n", optLeftList[i], optRightList[i])
+	}
+
+	// limits of the logs, also only written when set
+	if r.LogKeep > 0 {
+		strList += fmt.Sprintf("LogKeep = %v\n", r.LogKeep)
+	}
+	if r.LogSize > 0 {
+		strList += fmt.Sprintf("LogSize = %v\n", r.LogSize)
+	}
+	if r.SaveKeep > 0 {
+		strList += fmt.Sprintf("SaveKeep = %v\n", r.SaveKeep)
+	}
+
+	for _, override := range r.Dlls {
+		strList += fmt.Sprintf("Dll = %v\n", override.String())
+	}
+
+	for _, hook := range r.Hooks {
+		strList += fmt.Sprintf("%v = %v\n", hook.When, hook.Command)
+	}
+
+	var presetNames []string
+	for name := range r.DebugPresets {
+		presetNames = append(presetNames, name)
+	}
+	sort.Strings(presetNames)
+	for _, name := range presetNames {
+		strList += fmt.Sprintf("DebugPreset = %v %v\n", name, r.DebugPresets[name])
+	}
+
 	ioutil.WriteFile(
 		r.ConfigFile,
 		[]byte(strList),
@@ -131,75 +254,398 @@ func (r *Runner) RunnerWriteConfig() {
 	)
 }
 
-// run specified exe from the list of exes
-// choosing whether to fork the process or not
-func (r Runner) runFromList(elementNumber int, shouldFork bool) error {
-	// vars for storing target exe and its found stat
-	var targetExe Exe
-	var foundTarget bool
+// format a setting that is off unless set, as empty when off
+func formatOptBool(value bool) string {
+	if !value {
+		return ""
+	}
+	return strconv.FormatBool(value)
+}
 
-	// see if target exe is in the list
+// get the path of a prefix from its name, names that are paths
+// are used as they are and an empty name means the default prefix
+func (r Runner) prefixPath(name string) string {
+	switch {
+	case name == "":
+		if envPrefix := os.Getenv("WINEPREFIX"); envPrefix != "" {
+			return envPrefix
+		}
+		var homedir, _ = os.UserHomeDir()
+		return path.Join(homedir, ".wine")
+	case path.IsAbs(name):
+		return name
+	default:
+		return path.Join(r.PrefixDir, name)
+	}
+}
+
+// get the name a prefix would be given by, which is its path
+// relative to the prefix dir or the path itself if outside it
+func (r Runner) prefixName(prefixPath string) string {
+	if prefixPath == "" {
+		return ""
+	}
+
+	var relative, relErr = filepath.Rel(r.PrefixDir, prefixPath)
+	if r.PrefixDir == "" || relErr != nil || strings.HasPrefix(relative, "..") || relative == "." {
+		return path.Clean(prefixPath)
+	}
+
+	return relative
+}
+
+// get the dirs of all prefixes known, the default one, the ones in
+// the prefix dir and the ones entries of the list are bound to
+func (r Runner) knownPrefixes() (retList []string) {
+	var seen = make(map[string]bool)
+	var add = func(prefixDir string) {
+		if !seen[prefixDir] {
+			seen[prefixDir] = true
+			retList = append(retList, prefixDir)
+		}
+	}
+
+	// default prefix only if it was made
+	if _, statErr := os.Stat(r.prefixPath("")); statErr == nil {
+		add(r.prefixPath(""))
+	}
+
+	// dirs in the prefix dir that have a registry
+	var entries, _ = ioutil.ReadDir(r.PrefixDir)
+	for _, entry := range entries {
+		var prefixDir = path.Join(r.PrefixDir, entry.Name())
+		if _, statErr := os.Stat(path.Join(prefixDir, "system.reg")); entry.IsDir() && statErr == nil {
+			add(prefixDir)
+		}
+	}
+
+	for _, exeEntry := range r.List {
+		if exeEntry.Prefix != "" {
+			add(r.prefixPath(exeEntry.Prefix))
+		}
+	}
+
+	return
+}
+
+// get an exe from the list by its number
+func (r Runner) exeByNumber(elementNumber int) (Exe, error) {
 	for index, exeEntry := range r.List {
 		if index+1 == elementNumber {
-			targetExe = exeEntry
-			foundTarget = true
-			break
+			return exeEntry, nil
 		}
 	}
 
 	// if target was not found return not found error
-	if !foundTarget {
-		return fmt.Errorf("exe number %d: not in list", elementNumber)
+	return Exe{}, fmt.Errorf("exe number %d: not in list", elementNumber)
+}
+
+// make up the arguments of the command running an exe, with
+// or without arguments to the program and going through a
+// virtual desktop if the exe wants one
+func (r Runner) commandArgs(targetExe Exe) (retList []string) {
+	retList = append(retList, r.Program)
+	if r.ProgramArgs != "" {
+		retList = append(retList, r.ProgramArgs)
 	}
 
-	// make up command with or without arguments
-	var commandToRun *exec.Cmd
-	switch r.ProgramArgs {
-	case "":
-		commandToRun = exec.Command(r.Program, targetExe.Path)
-	default:
-		commandToRun = exec.Command(r.Program, r.ProgramArgs, targetExe.Path)
+	if targetExe.Desktop != "" {
+		retList = append(retList, "explorer", "/desktop="+targetExe.desktopName()+","+targetExe.Desktop)
+
+		// explorer takes the path as a windows one, so a unix
+		// path goes through start, which finds it with or
+		// without a drive mapped to it
+		if !isWindowsPath(targetExe.Path) {
+			retList = append(retList, "start", "/wait", "/unix")
+		}
 	}
 
+	return append(retList, targetExe.Path)
+}
+
+// make up the variables added to the environment of the command
+// running an exe, the prefix it is bound to if any and the dll
+// overrides of the program, its prefix and itself
+func (r Runner) commandEnv(targetExe Exe, prefixConfig PrefixConfig) (retList []string) {
+	if targetExe.Prefix != "" {
+		retList = append(retList, "WINEPREFIX="+r.prefixPath(targetExe.Prefix))
+	}
+
+	var dlls = mergeDllOverrides(r.Dlls, prefixConfig.Dlls, targetExe.Dlls)
+	if len(dlls) > 0 {
+		retList = append(retList, "WINEDLLOVERRIDES="+dllOverridesEnv(dlls))
+	}
+
+	return
+}
+
+// get a prefix ready for running an exe in it, isolating it if set
+// and mapping the drives and changing the registry values the exe
+// needs, returns the settings of the prefix and a function putting
+// back the drives and registry (when set to) if restore is wanted
+func (r Runner) preparePrefix(targetExe Exe, restore bool) (ret PrefixConfig, restoreFunc func(), retErr error) {
+	var restoreList []func()
+	restoreFunc = func() {
+		for _, f := range restoreList {
+			f()
+		}
+	}
+
+	// settings of the prefix the exe runs in
+	var prefixDir = r.prefixPath(targetExe.Prefix)
+	ret, retErr = readPrefixConfig(prefixDir)
+	if retErr != nil {
+		return
+	}
+
+	// take out links to the host that wine might have put back
+	if ret.Isolation {
+		var isolateErr = applyIsolation(prefixDir, &ret)
+		if isolateErr != nil {
+			return ret, restoreFunc, fmt.Errorf("could not isolate prefix %s: %s", prefixDir, isolateErr.Error())
+		}
+		ret.write(prefixDir)
+	}
+
+	// map the drives the exe needs, its own over the prefix ones
+	var drives = mergeDriveMaps(ret.Drives, targetExe.Drives)
+	if len(drives) > 0 {
+		var previousDrives, applyErr = applyDrives(prefixDir, drives)
+		if applyErr != nil {
+			restoreDrives(prefixDir, previousDrives)
+			return ret, restoreFunc, applyErr
+		}
+
+		if r.RestoreDrives && restore {
+			restoreList = append(restoreList, func() { restoreDrives(prefixDir, previousDrives) })
+		}
+	}
+
+	// change the registry values the exe needs, which wine
+	// reads when its server starts for the prefix
+	var tweaks = append(targetExe.versionTweaks(), targetExe.Registry...)
+	if len(tweaks) > 0 {
+		if prefixRunning(prefixDir) {
+			fmt.Fprintf(os.Stderr, "winela: warning: %s is running, its wineserver keeps its own registry and may undo the changes when it ends\n", prefixDir)
+		}
+
+		var previousRegistry, regErr = applyRegTweaks(prefixDir, tweaks)
+		if regErr != nil {
+			return ret, restoreFunc, fmt.Errorf("could not change registry: %s", regErr.Error())
+		}
+
+		if r.RestoreRegistry && restore {
+			// the wineserver writes its registry back when it ends,
+			// which would overwrite the restored values
+			restoreList = append([]func(){func() {
+				r.waitWineserver(prefixDir)
+				applyRegTweaks(prefixDir, previousRegistry)
+			}}, restoreList...)
+		}
+	}
+
+	return
+}
+
+// make up the session of an exe started from the list
+func (r Runner) newSession(elementNumber int, targetExe Exe, result RunResult, startTime time.Time) Session {
+	return Session{
+		Entry:   elementNumber,
+		Name:    targetExe.Name,
+		Path:    targetExe.Path,
+		Pid:     result.Pid,
+		Prefix:  r.prefixPath(targetExe.Prefix),
+		Started: startTime,
+		Log:     result.Log,
+	}
+}
+
+// make up a command from its arguments, with
+// variables added to the environment if any
+func (r Runner) makeCommand(commandArgs []string, addedEnv []string) *exec.Cmd {
+	var commandToRun = exec.Command(commandArgs[0], commandArgs[1:]...)
+	if len(addedEnv) > 0 {
+		commandToRun.Env = append(os.Environ(), addedEnv...)
+	}
+	return commandToRun
+}
+
+// run specified exe from the list of exes
+// choosing whether to fork the process or not, forked ones
+// are detached and recorded as sessions, the others are
+// waited for and how they ended returned
+func (r Runner) runFromList(elementNumber int, shouldFork bool) (ret RunResult, retErr error) {
+	// see if target exe is in the list
+	var targetExe, findErr = r.exeByNumber(elementNumber)
+	if findErr != nil {
+		return ret, findErr
+	}
+
+	// see how long it may run, not starting it with no time left or
+	// what is to run alone while it already runs, the locks go to
+	// the session once started and are let go with it
+	var left, limited, locks, admitErr = r.admitLaunch(targetExe)
+	if admitErr != nil {
+		return ret, admitErr
+	}
+	var lockTied bool
+	defer func() {
+		if !lockTied {
+			removeLocks(locks)
+		}
+	}()
+
+	// keep a log of the launch if there is a state dir to keep it in
+	var logFile *os.File
+	if r.StateDir != "" {
+		var logErr error
+		logFile, logErr = r.createLaunchLog(targetExe, time.Now())
+		if logErr != nil {
+			return ret, fmt.Errorf("could not make log: %s", logErr.Error())
+		}
+		defer logFile.Close()
+		ret.Log = logFile.Name()
+	}
+
+	// the hooks before the launch can stop it
+	var hookSession = r.newSession(elementNumber, targetExe, ret, time.Now())
+	var preHooks = r.hooks(targetExe, "PreLaunch")
+	if hookErr := runHooks(preHooks, hookEnv("PreLaunch", hookSession, ""), optionalWriter(logFile), os.Stdout); hookErr != nil {
+		return ret, fmt.Errorf("pre-launch %s", hookErr.Error())
+	}
+
+	// check the exe is still the one added, after the hooks
+	// that could have made it there
+	if verifyErr := r.verifyBeforeLaunch(targetExe, optionalWriter(logFile), os.Stderr); verifyErr != nil {
+		return ret, verifyErr
+	}
+
+	// keep the saves as they were before, a launch not being
+	// stopped by saves that could not be kept
+	if logFile != nil && len(targetExe.Saves) != 0 {
+		if snapshot, snapshotErr := r.snapshotSaves(targetExe, time.Now()); snapshotErr != nil {
+			logFile.WriteString(logLine("SAVE:", "could not snapshot saves: "+snapshotErr.Error(), time.Now()))
+		} else {
+			logFile.WriteString(logLine("SAVE:", "saves snapshotted to "+snapshot, time.Now()))
+		}
+	}
+
+	// put back what was changed in the prefix once done,
+	// which is only known when not forking
+	var prefixConfig, restorePrefix, prepareErr = r.preparePrefix(targetExe, !shouldFork)
+	if prepareErr != nil {
+		return ret, prepareErr
+	}
+	defer restorePrefix()
+
+	// make up command
+	var commandArgs = r.commandArgs(targetExe)
+	var commandEnv = r.commandEnv(targetExe, prefixConfig)
+	var commandToRun = r.makeCommand(commandArgs, commandEnv)
+	var startTime = time.Now()
+
 	if shouldFork {
-		// start and letgo
-		var execErr = commandToRun.Start()
-		if execErr != nil {
-			return fmt.Errorf("could not execute %s: %s", r.Program, execErr.Error())
+		if logFile != nil {
+			// a winela left in the background starts it and writes its
+			// output to the log, which also stops it once out of time
+			// and runs the hooks after it exits with how it ended
+			var job = logWriterJob{
+				Command: newLaunchCommand(commandArgs, commandEnv),
+				Session: hookSession,
+				Hooks:   r.logWriterHooks(targetExe),
+			}
+			var pid, startErr = startLogWriter(ret.Log, r.StateDir, left, job)
+			if startErr != nil {
+				var text = fmt.Sprintf("could not execute %s: %s", r.Program, startErr.Error())
+				logFile.WriteString(logLine("ERR:", text, time.Now()))
+				return ret, fmt.Errorf("%s", text)
+			}
+			ret.Pid = pid
+		} else {
+			// detach from the terminal in a session of its own, start and letgo
+			commandToRun.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+			var execErr = commandToRun.Start()
+			if execErr != nil {
+				return ret, fmt.Errorf("could not execute %s: %s", r.Program, execErr.Error())
+			}
+			ret.Pid = commandToRun.Process.Pid
+			commandToRun.Process.Release()
 		}
-	} else {
-		// for non-repetition
-		var scanPipe = func(prefix string, scanner *bufio.Scanner, channel chan bool) {
-			for scanner.Scan() {
-				fmt.Println(prefix, scanner.Text())
+
+		// remember it to find it later
+		if r.StateDir != "" {
+			if writeErr := r.writeSession(r.newSession(elementNumber, targetExe, ret, startTime)); writeErr != nil {
+				return ret, fmt.Errorf("could not record session: %s", writeErr.Error())
 			}
-			channel <- true
+			tieLocks(locks, ret.Pid)
+			lockTied = true
 		}
 
-		// pipe errors to a reader and scan it in a go routine
+		// tell the hooks after the start
+		var session = r.newSession(elementNumber, targetExe, ret, startTime)
+		runHooks(r.hooks(targetExe, "PostLaunch"), hookEnv("PostLaunch", session, ""), optionalWriter(logFile), os.Stdout)
+	} else {
+		// pipe errors and output to readers
 		var errReader, _ = commandToRun.StderrPipe()
-		var errScanner = bufio.NewScanner(errReader)
 		var finishedError = make(chan bool)
-		go scanPipe("ERR:", errScanner, finishedError)
-
-		// pipe output to a reader and scan it in a go routine
 		var outReader, _ = commandToRun.StdoutPipe()
-		var outScanner = bufio.NewScanner(outReader)
 		var finishedOutput = make(chan bool)
-		go scanPipe("OUT:", outScanner, finishedOutput)
 
-		// run the command
+		// run the command in a process group of its own, which
+		// gets the signals ending winela passed on to it
+		commandToRun.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 		var execErr = commandToRun.Start()
 		if execErr != nil {
-			return fmt.Errorf("could not execute %s: %s", r.Program, execErr.Error())
+			return ret, fmt.Errorf("could not execute %s: %s", r.Program, execErr.Error())
+		}
+		var stopForwarding = forwardSignals(commandToRun.Process.Pid)
+		defer stopForwarding()
+
+		// and scan them in go routines, lines going
+		// to the terminal and the log
+		go logStream("ERR:", errReader, optionalWriter(logFile), os.Stdout, finishedError)
+		go logStream("OUT:", outReader, optionalWriter(logFile), os.Stdout, finishedOutput)
+
+		// remember it while it runs
+		ret.Pid = commandToRun.Process.Pid
+		var session = r.newSession(elementNumber, targetExe, ret, startTime)
+		// and stop it once out of time
+		if r.StateDir != "" {
+			var endSession = r.trackSession(session, locks, left, limited, r.hooks(targetExe, "LimitWarning"), optionalWriter(logFile))
+			lockTied = true
+			defer endSession()
 		}
 
+		// tell the hooks after the start
+		runHooks(r.hooks(targetExe, "PostLaunch"), hookEnv("PostLaunch", session, ""), optionalWriter(logFile), os.Stdout)
+
 		// wait until channels send finish bool
 		<-finishedError
 		<-finishedOutput
+
+		// then for the process to end, an exit code
+		// or a signal ending it is not an error of winela
+		var logPath = ret.Log
+		var waitErr = commandToRun.Wait()
+		if _, isExitErr := waitErr.(*exec.ExitError); waitErr != nil && !isExitErr {
+			return commandResult(commandToRun, startTime), fmt.Errorf("could not wait for %s: %s", r.Program, waitErr.Error())
+		}
+
+		// and for what else it left running if wanted
+		var afterErr = r.waitAfter(r.waitStrategy(targetExe), commandToRun.Process.Pid, r.prefixPath(targetExe.Prefix))
+		ret = commandResult(commandToRun, startTime)
+		ret.Pid = commandToRun.Process.Pid
+		ret.Log = logPath
+
+		// and tell the hooks after the exit how it went
+		runHooks(r.hooks(targetExe, "PostExit"), hookEnv("PostExit", session, strconv.Itoa(ret.code())), optionalWriter(logFile), os.Stdout)
+
+		if afterErr != nil {
+			return ret, afterErr
+		}
 	}
 
-	return nil
+	return
 }
 
 // return the list as a numbered string
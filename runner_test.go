@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"testing"
 )
 
@@ -175,7 +176,7 @@ func TestRunFromList(t *testing.T) {
 				Program:     "wine",
 				ProgramArgs: "",
 				List: []Exe{
-					{"PS", inTestDir("PS.exe")},
+					{Name: "PS", Path: inTestDir("PS.exe")},
 				},
 			},
 			ParamRunProg: 5,
@@ -185,7 +186,7 @@ func TestRunFromList(t *testing.T) {
 
 	for _, testCase := range testTable {
 		t.Run(testCase.Description, func(t *testing.T) {
-			var gottenErr = testCase.ParamRunner.runFromList(testCase.ParamRunProg, testCase.ParamFork)
+			var _, gottenErr = testCase.ParamRunner.runFromList(testCase.ParamRunProg, testCase.ParamFork)
 
 			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
 				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
@@ -207,8 +208,8 @@ func TestDisplayList(t *testing.T) {
 				Program:     "wine",
 				ProgramArgs: "",
 				List: []Exe{
-					{"sr", inTestDir("sr.exe")},
-					{"lon", inTestDir("lon.exe")},
+					{Name: "sr", Path: inTestDir("sr.exe")},
+					{Name: "lon", Path: inTestDir("lon.exe")},
 				},
 			},
 		},
@@ -225,3 +226,53 @@ func TestDisplayList(t *testing.T) {
 	}
 
 }
+
+func TestPrefixName(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamRunner Runner
+		ParamPath   string
+	}{
+		{
+			Description: "prefix inside the prefix dir",
+			Expected:    "games/old",
+			ParamRunner: Runner{PrefixDir: "/home/u/prefixes"},
+			ParamPath:   "/home/u/prefixes/games/old/",
+		},
+		{
+			Description: "prefix outside the prefix dir",
+			Expected:    "/home/u/.wine",
+			ParamRunner: Runner{PrefixDir: "/home/u/prefixes"},
+			ParamPath:   "/home/u/.wine",
+		},
+		{
+			Description: "prefix dir itself",
+			Expected:    "/home/u/prefixes",
+			ParamRunner: Runner{PrefixDir: "/home/u/prefixes"},
+			ParamPath:   "/home/u/prefixes",
+		},
+		{
+			Description: "no prefix",
+			Expected:    "",
+			ParamRunner: Runner{PrefixDir: "/home/u/prefixes"},
+			ParamPath:   "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamRunner.prefixName(testCase.ParamPath)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			// names lead back to the same prefix
+			if gotten != "" && testCase.ParamRunner.prefixPath(gotten) != path.Clean(testCase.ParamPath) {
+				errorExpGot(t, path.Clean(testCase.ParamPath), testCase.ParamRunner.prefixPath(gotten), false)
+			}
+		})
+	}
+}
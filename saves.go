@@ -0,0 +1,278 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how many snapshots of the saves of an entry are kept by default
+var defaultSaveKeep = 10
+
+// a windows variable in a save location like %APPDATA%
+var saveVarPattern = regexp.MustCompile(`%[A-Za-z_]+%`)
+
+// get the dir the snapshots of the saves of an entry are kept in
+func (r Runner) saveDir(targetExe Exe) string {
+	return path.Join(r.StateDir, "saves", entryFileName(targetExe))
+}
+
+// get how many snapshots of saves to keep, as set or else the default
+func (r Runner) saveKeep() int {
+	if r.SaveKeep > 0 {
+		return r.SaveKeep
+	}
+	return defaultSaveKeep
+}
+
+// get the folders of the user of a prefix by the windows variables
+// for them, the user being the one running winela if it has a folder
+// in the prefix or else the first one that is not Public
+func windowsFolders(prefixDir string) map[string]string {
+	var usersDir = path.Join(prefixDir, "drive_c", "users")
+	var user = os.Getenv("USER")
+	if _, statErr := os.Stat(path.Join(usersDir, user)); user == "" || statErr != nil {
+		var users, _ = ioutil.ReadDir(usersDir)
+		for _, entry := range users {
+			if entry.IsDir() && entry.Name() != "Public" {
+				user = entry.Name()
+				break
+			}
+		}
+	}
+
+	var profile = `C:\users\` + user
+	var ret = map[string]string{
+		"USERPROFILE":  profile,
+		"APPDATA":      profile + `\AppData\Roaming`,
+		"LOCALAPPDATA": profile + `\AppData\Local`,
+		"PUBLIC":       `C:\users\Public`,
+		"PROGRAMDATA":  `C:\ProgramData`,
+	}
+
+	// older prefixes have the folders of windows xp
+	if _, statErr := os.Stat(path.Join(usersDir, user, "Application Data")); statErr == nil {
+		ret["APPDATA"] = profile + `\Application Data`
+		ret["LOCALAPPDATA"] = profile + `\Local Settings\Application Data`
+	}
+
+	return ret
+}
+
+// get the unix path of a save location of an entry, given as a unix
+// path or a windows one (with variables like %APPDATA% in it) that
+// is looked up in the prefix
+func savePath(prefixDir string, value string) (string, error) {
+	var folders = windowsFolders(prefixDir)
+	var unknown string
+	var expanded = saveVarPattern.ReplaceAllStringFunc(value, func(variable string) string {
+		if folder, found := folders[strings.ToUpper(strings.Trim(variable, "%"))]; found {
+			return folder
+		}
+		unknown = variable
+		return variable
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("save %q: %s is not a known folder", value, unknown)
+	}
+
+	switch {
+	case isWindowsPath(expanded):
+		return unixFromWindows(prefixDir, expanded)
+	case strings.HasPrefix(expanded, "~/"):
+		var homedir, _ = os.UserHomeDir()
+		return path.Join(homedir, expanded[2:]), nil
+	case path.IsAbs(expanded):
+		return path.Clean(expanded), nil
+	default:
+		return "", fmt.Errorf("save %q: needs a full unix or windows path", value)
+	}
+}
+
+// get the snapshots of saves in a dir, the latest first
+func savesInDir(dir string) (retList []string) {
+	var entries, _ = ioutil.ReadDir(dir)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tar.gz") {
+			retList = append(retList, path.Join(dir, entry.Name()))
+		}
+	}
+
+	// named by when they were made
+	sort.Sort(sort.Reverse(sort.StringSlice(retList)))
+	return
+}
+
+// remove the oldest snapshots of saves in a dir over the ones to keep
+func pruneSaves(dir string, keep int) (retErr error) {
+	for i, snapshot := range savesInDir(dir) {
+		if i < keep {
+			continue
+		}
+		if removeErr := os.Remove(snapshot); removeErr != nil {
+			retErr = removeErr
+		}
+	}
+	return
+}
+
+// add a file or a dir with what is in it to a tar archive under a name
+func addToTar(tarWriter *tar.Writer, source string, name string) error {
+	return filepath.Walk(source, func(filePath string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		// only dirs and files are saves
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		var relPath, _ = filepath.Rel(source, filePath)
+		var header, headerErr = tar.FileInfoHeader(info, "")
+		if headerErr != nil {
+			return headerErr
+		}
+		header.Name = path.Join(name, relPath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if writeErr := tarWriter.WriteHeader(header); writeErr != nil {
+			return writeErr
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		var file, openErr = os.Open(filePath)
+		if openErr != nil {
+			return openErr
+		}
+		defer file.Close()
+
+		var _, copyErr = io.Copy(tarWriter, file)
+		return copyErr
+	})
+}
+
+// make a compressed snapshot of the save locations of an entry, each
+// stored under its number among them (leaving out the ones that are
+// not there yet), then drop the oldest snapshots over the ones kept
+func (r Runner) snapshotSaves(targetExe Exe, now time.Time) (ret string, retErr error) {
+	if len(targetExe.Saves) == 0 {
+		return "", fmt.Errorf("%s has no save locations", targetExe.Name)
+	}
+
+	var dir = r.saveDir(targetExe)
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return "", mkdirErr
+	}
+
+	ret = path.Join(dir, now.Format("20060102-150405.000")+".tar.gz")
+	var file, createErr = os.Create(ret)
+	if createErr != nil {
+		return "", createErr
+	}
+	var gzipWriter = gzip.NewWriter(file)
+	var tarWriter = tar.NewWriter(gzipWriter)
+
+	var prefixDir = r.prefixPath(targetExe.Prefix)
+	for i, save := range targetExe.Saves {
+		var location, pathErr = savePath(prefixDir, save)
+		if pathErr != nil {
+			retErr = pathErr
+			break
+		}
+
+		if _, statErr := os.Stat(location); os.IsNotExist(statErr) {
+			continue
+		}
+
+		if addErr := addToTar(tarWriter, location, strconv.Itoa(i+1)); addErr != nil {
+			retErr = addErr
+			break
+		}
+	}
+
+	// an archive that was not fully written is no snapshot
+	for _, closer := range []io.Closer{tarWriter, gzipWriter, file} {
+		if closeErr := closer.Close(); closeErr != nil && retErr == nil {
+			retErr = closeErr
+		}
+	}
+	if retErr != nil {
+		os.Remove(ret)
+		return "", retErr
+	}
+
+	pruneSaves(dir, r.saveKeep())
+	return
+}
+
+// copy a file or a dir with what is in it
+func copyTree(source string, target string) error {
+	return filepath.Walk(source, func(filePath string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		var relPath, _ = filepath.Rel(source, filePath)
+		if info.IsDir() {
+			return os.MkdirAll(path.Join(target, relPath), 0755)
+		}
+		return copyFile(filePath, path.Join(target, relPath))
+	})
+}
+
+// put the saves of a snapshot in place of the ones there now, which
+// are snapshotted first so the restore can be undone, the locations
+// not in the snapshot are left as they are
+func (r Runner) restoreSaves(targetExe Exe, snapshot string) error {
+	var tempDir, tempErr = ioutil.TempDir("", "winela-saves")
+	if tempErr != nil {
+		return tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	// read before snapshotting, which could prune it
+	if extractErr := extractTar(snapshot, tempDir); extractErr != nil {
+		return extractErr
+	}
+
+	if _, snapshotErr := r.snapshotSaves(targetExe, time.Now()); snapshotErr != nil {
+		return fmt.Errorf("could not snapshot the saves there now: %s", snapshotErr.Error())
+	}
+
+	var prefixDir = r.prefixPath(targetExe.Prefix)
+	for i, save := range targetExe.Saves {
+		var stored = path.Join(tempDir, strconv.Itoa(i+1))
+		if _, statErr := os.Stat(stored); statErr != nil {
+			continue
+		}
+
+		var location, pathErr = savePath(prefixDir, save)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		if removeErr := os.RemoveAll(location); removeErr != nil {
+			return removeErr
+		}
+		if copyErr := copyTree(stored, location); copyErr != nil {
+			return copyErr
+		}
+	}
+
+	return nil
+}
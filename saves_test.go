@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSavePath(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeTestPrefix(prefixDir)
+	os.MkdirAll(prefixDir+"/drive_c/users/Public", 0755)
+	os.MkdirAll(prefixDir+"/drive_c/users/gamer/AppData/Roaming/Flap", 0755)
+
+	// the user is not the one running the test
+	var user = os.Getenv("USER")
+	os.Setenv("USER", "nobody-here")
+	defer os.Setenv("USER", user)
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+		ExpectedErr error
+
+		ParamValue string
+	}{
+		{
+			Description: "windows path with a folder variable",
+			Expected:    prefixDir + "/drive_c/users/gamer/AppData/Roaming/Flap/saves",
+			ExpectedErr: nil,
+			ParamValue:  `%appdata%\flap\saves`,
+		},
+		{
+			Description: "unix path",
+			Expected:    "/games/Flap/saves",
+			ExpectedErr: nil,
+			ParamValue:  "/games/Flap/saves/",
+		},
+		{
+			Description: "unknown folder variable",
+			Expected:    "",
+			ExpectedErr: fmt.Errorf(`save "%%SAVES%%\\Flap": %%SAVES%% is not a known folder`),
+			ParamValue:  `%SAVES%\Flap`,
+		},
+		{
+			Description: "relative path",
+			Expected:    "",
+			ExpectedErr: fmt.Errorf(`save "Flap/saves": needs a full unix or windows path`),
+			ParamValue:  "Flap/saves",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = savePath(prefixDir, testCase.ParamValue)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestSnapshotRestoreSaves(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var saveDir, _ = filepath.Abs(inTestDir("saves/Flap"))
+	var saveFile, _ = filepath.Abs(inTestDir("flap.cfg"))
+	os.MkdirAll(saveDir+"/slot1", 0755)
+	ioutil.WriteFile(saveDir+"/slot1/save.dat", []byte("level 3"), 0644)
+	ioutil.WriteFile(saveFile, []byte("hard"), 0644)
+
+	var rnr = Runner{StateDir: inTestDir("state"), SaveKeep: 2}
+	var targetExe = Exe{
+		Name:  "Flap",
+		Path:  "/games/Flap.exe",
+		Saves: []string{saveDir, saveFile, saveDir + "/not-made-yet"},
+	}
+
+	var started = time.Now()
+	var snapshot, snapshotErr = rnr.snapshotSaves(targetExe, started)
+	if snapshotErr != nil {
+		errorExpGot(t, nil, snapshotErr, true)
+	}
+
+	// lost to a crash
+	os.RemoveAll(saveDir + "/slot1")
+	ioutil.WriteFile(saveFile, []byte("broken"), 0644)
+	ioutil.WriteFile(saveDir+"/corrupt.dat", []byte("?"), 0644)
+
+	if restoreErr := rnr.restoreSaves(targetExe, snapshot); restoreErr != nil {
+		errorExpGot(t, nil, restoreErr, true)
+	}
+
+	var gotten = ""
+	for _, restored := range []string{saveDir + "/slot1/save.dat", saveFile} {
+		var data, _ = ioutil.ReadFile(restored)
+		gotten += string(data) + "\n"
+	}
+	if _, statErr := os.Stat(saveDir + "/corrupt.dat"); statErr == nil {
+		gotten += "corrupt.dat\n"
+	}
+
+	if expected := "level 3\nhard\n"; expected != gotten {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	// the saves before the restore are kept, up to the ones to keep
+	rnr.snapshotSaves(targetExe, started.Add(time.Hour))
+	if snapshots := savesInDir(rnr.saveDir(targetExe)); len(snapshots) != 2 {
+		errorExpGot(t, 2, len(snapshots), false)
+	}
+}
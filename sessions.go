@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// a process started from the list, recorded in
+// the state dir so it can be found after winela exits
+type Session struct {
+	Entry   int
+	Name    string
+	Path    string
+	Pid     int
+	Prefix  string
+	Started time.Time
+	Log     string
+}
+
+// get the dir sessions are recorded in
+func (r Runner) sessionsDir() string {
+	return path.Join(r.StateDir, "sessions")
+}
+
+// get the file a session is recorded in, named by its pid
+func (r Runner) sessionFile(pid int) string {
+	return path.Join(r.sessionsDir(), strconv.Itoa(pid))
+}
+
+// record a session in the state dir
+func (r Runner) writeSession(s Session) error {
+	if mkdirErr := os.MkdirAll(r.sessionsDir(), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	var leftList = []string{
+		"Entry", "Name", "Path", "Pid", "Prefix", "Started", "Log",
+	}
+	var rightList = []string{
+		strconv.Itoa(s.Entry), s.Name, s.Path, strconv.Itoa(s.Pid), s.Prefix, s.Started.Format(time.RFC3339), s.Log,
+	}
+
+	var strList string
+	for i := range leftList {
+		strList += fmt.Sprintf("%s = %s\n", leftList[i], rightList[i])
+	}
+
+	return ioutil.WriteFile(r.sessionFile(s.Pid), []byte(strList), 0644)
+}
+
+// read a session recorded in the state dir
+func readSession(fileName string) (ret Session, retErr error) {
+	var readData, readErr = ioutil.ReadFile(fileName)
+	if readErr != nil {
+		return ret, readErr
+	}
+
+	for _, line := range strings.Split(string(readData), "\n") {
+		var pair = strings.SplitN(line, "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		var left = strings.TrimSpace(pair[0])
+		var right = strings.TrimSpace(pair[1])
+
+		switch left {
+		case "Entry":
+			ret.Entry, _ = strconv.Atoi(right)
+		case "Name":
+			ret.Name = right
+		case "Path":
+			ret.Path = right
+		case "Pid":
+			ret.Pid, _ = strconv.Atoi(right)
+		case "Prefix":
+			ret.Prefix = right
+		case "Started":
+			ret.Started, _ = time.Parse(time.RFC3339, right)
+		case "Log":
+			ret.Log = right
+		}
+	}
+
+	if ret.Pid == 0 {
+		return ret, fmt.Errorf("%s: no pid recorded", fileName)
+	}
+
+	return
+}
+
+// keep track of a program started without forking while it runs,
+// recording its session, tying the locks taken for it to it and
+// stopping it once out of time, returning what ends the session
+func (r Runner) trackSession(s Session, locks []string, left time.Duration, limited bool, warnHooks []string, logFile io.Writer) (endFunc func()) {
+	r.writeSession(s)
+	tieLocks(locks, s.Pid)
+
+	var limitDone = make(chan bool)
+	if limited {
+		go r.enforceLimit(s, left, warnHooks, logFile, os.Stderr, limitDone)
+	}
+
+	return func() {
+		close(limitDone)
+		r.endSession(s, time.Now())
+	}
+}
+
+// forget a session that ended and add it to the history of
+// launches, only once as whoever removes its file records it
+func (r Runner) endSession(s Session, ended time.Time) error {
+	if removeErr := os.Remove(r.sessionFile(s.Pid)); removeErr != nil {
+		return removeErr
+	}
+	r.releaseLocks(s.Pid)
+
+	return r.recordLaunch(Launch{
+		Started:  s.Started,
+		Duration: ended.Sub(s.Started),
+		Name:     s.Name,
+		Path:     s.Path,
+	})
+}
+
+// end the session writing to a log, which the winela writing the
+// log of a detached program does once the program stops writing
+func (r Runner) endSessionOfLog(logPath string, ended time.Time) {
+	if s, found := r.sessionOfLog(logPath); found {
+		r.endSession(s, ended)
+	}
+}
+
+// get the session writing to a log
+func (r Runner) sessionOfLog(logPath string) (Session, bool) {
+	var entries, _ = ioutil.ReadDir(r.sessionsDir())
+	for _, entry := range entries {
+		var s, sessionErr = readSession(path.Join(r.sessionsDir(), entry.Name()))
+		if sessionErr == nil && s.Log == logPath {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// check if a session is still running, which is while
+// any process of its process group is left
+func sessionAlive(s Session) bool {
+	var killErr = syscall.Kill(-s.Pid, 0)
+	return killErr == nil || killErr == syscall.EPERM
+}
+
+// get the sessions still running from the state dir by when they
+// started, forgetting the ones that ended
+func (r Runner) liveSessions() (retList []Session, retErr error) {
+	var entries, readErr = ioutil.ReadDir(r.sessionsDir())
+	if os.IsNotExist(readErr) {
+		return nil, nil
+	} else if readErr != nil {
+		return nil, readErr
+	}
+
+	for _, entry := range entries {
+		var fileName = path.Join(r.sessionsDir(), entry.Name())
+		var s, sessionErr = readSession(fileName)
+		if sessionErr != nil {
+			os.Remove(fileName)
+			continue
+		}
+
+		// sessions that ended on their own last wrote to their log
+		if !sessionAlive(s) {
+			var ended = time.Now()
+			if info, statErr := os.Stat(s.Log); s.Log != "" && statErr == nil {
+				ended = info.ModTime()
+			}
+			r.endSession(s, ended)
+			continue
+		}
+		retList = append(retList, s)
+	}
+
+	sort.Slice(retList, func(i, j int) bool {
+		return retList[i].Started.Before(retList[j].Started)
+	})
+
+	return
+}
+
+// return sessions as lines of text
+func displaySessions(list []Session) (ret string) {
+	for _, s := range list {
+		ret += fmt.Sprintf("%v %v (pid %v since %s in %s)\n",
+			s.Entry, s.Name, s.Pid, s.Started.Format("2006-01-02 15:04"), s.Prefix)
+	}
+	return
+}
+
+// get the wineserver that comes with the wine program
+func (r Runner) wineserverProgram() string {
+	if strings.Contains(r.Program, "/") {
+		return path.Join(path.Dir(r.Program), "wineserver")
+	}
+	return "wineserver"
+}
+
+// stop a session by asking its process group to end and if it is
+// still there after the timeout, killing the wineserver of its
+// prefix (which ends every windows process in it) and the group
+func (r Runner) stopSession(s Session, timeout time.Duration) error {
+	if killErr := syscall.Kill(-s.Pid, syscall.SIGTERM); killErr != nil && killErr != syscall.ESRCH {
+		return fmt.Errorf("could not stop pid %d: %s", s.Pid, killErr.Error())
+	}
+
+	for waited := time.Duration(0); waited < timeout; waited += 100 * time.Millisecond {
+		if !sessionAlive(s) {
+			r.endSession(s, time.Now())
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	r.killWineserver(s.Prefix)
+	syscall.Kill(-s.Pid, syscall.SIGKILL)
+
+	r.endSession(s, time.Now())
+	return nil
+}
+
+// end everything wine runs in a prefix
+func (r Runner) killWineserver(prefix string) {
+	var killServer = exec.Command(r.wineserverProgram(), "-k")
+	killServer.Env = append(os.Environ(), "WINEPREFIX="+prefix)
+	killServer.Run()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWriteReadSession(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{StateDir: inTestDir("state")}
+	var started, _ = time.Parse(time.RFC3339, "2026-10-19T20:30:00Z")
+	var written = Session{
+		Entry:   2,
+		Name:    "Game = Good",
+		Path:    "/games/Game.exe",
+		Pid:     4242,
+		Prefix:  "/prefixes/games",
+		Started: started,
+		Log:     inTestDir("state/logs/Game/20261019-203000.000.log"),
+	}
+
+	var writeErr = rnr.writeSession(written)
+	var gotten, readErr = readSession(rnr.sessionFile(4242))
+
+	if fmt.Sprint(written) != fmt.Sprint(gotten) {
+		errorExpGot(t, written, gotten, false)
+	}
+
+	if writeErr != nil || readErr != nil {
+		errorExpGot(t, nil, []error{writeErr, readErr}, true)
+	}
+}
+
+func TestLogDir(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamName string
+	}{
+		{
+			Description: "plain name",
+			Expected:    "/state/logs/My Game",
+			ParamName:   "My Game",
+		},
+		{
+			Description: "name with a slash",
+			Expected:    "/state/logs/AC_DC",
+			ParamName:   "AC/DC",
+		},
+		{
+			Description: "name going up",
+			Expected:    "/state/logs/_",
+			ParamName:   "..",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = Runner{StateDir: "/state"}.logDir(Exe{Name: testCase.ParamName})
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestRunFromListDetached(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		List: []Exe{
+			{Name: "Script", Path: "echo out; echo err >&2"},
+		},
+	}
+
+	var gotten, gottenErr = rnr.runFromList(1, true)
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+
+	var session, readErr = readSession(rnr.sessionFile(gotten.Pid))
+	if readErr != nil || session.Entry != 1 || session.Log != gotten.Log {
+		errorExpGot(t, gotten, session, false)
+	}
+
+	// the output ends up in the log once the process is done
+	var logData []byte
+	for tries := 0; tries < 100 && strings.Count(string(logData), "\n") < 2; tries++ {
+		time.Sleep(20 * time.Millisecond)
+		logData, _ = ioutil.ReadFile(gotten.Log)
+	}
+
+	var logged = string(logData)
+	if !strings.Contains(logged, " OUT: out\n") || !strings.Contains(logged, " ERR: err\n") {
+		errorExpGot(t, "OUT: out and ERR: err", logged, false)
+	}
+}
+
+func TestRunFromListDetachedNotStarted(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// a program that is not there can't be started
+	var rnr = Runner{
+		Program:  inTestDir("wine"),
+		StateDir: inTestDir("state"),
+		Hooks:    []Hook{{When: "PostExit", Command: "touch " + inTestDir("ran")}},
+		List: []Exe{
+			{Name: "Game", Path: "/games/game.exe"},
+		},
+	}
+
+	var gotten, gottenErr = rnr.runFromList(1, true)
+
+	if gottenErr == nil || gotten.Pid != 0 {
+		errorExpGot(t, "an error and no pid", gotten, false)
+	}
+
+	// give a wrongly left log writer the time to run the hooks
+	time.Sleep(300 * time.Millisecond)
+	if _, statErr := os.Stat(inTestDir("ran")); statErr == nil {
+		errorExpGot(t, "no hook after the exit", "hook run", false)
+	}
+
+	if sessions, _ := rnr.liveSessions(); len(sessions) != 0 {
+		errorExpGot(t, "no session", sessions, false)
+	}
+
+	var logData, _ = ioutil.ReadFile(gotten.Log)
+	if !strings.Contains(string(logData), " ERR: could not execute testground/wine: ") {
+		errorExpGot(t, "the failure in the log", string(logData), false)
+	}
+}
+
+func TestLiveSessions(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{StateDir: inTestDir("state")}
+
+	// a process that ended and one still running
+	var ended = exec.Command("true")
+	ended.Run()
+	var running = exec.Command("sleep", "5")
+	running.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	running.Start()
+	go running.Wait()
+
+	rnr.writeSession(Session{Entry: 1, Name: "Ended", Pid: ended.Process.Pid})
+	rnr.writeSession(Session{Entry: 2, Name: "Running", Pid: running.Process.Pid})
+
+	var gotten, gottenErr = rnr.liveSessions()
+
+	if len(gotten) != 1 || gotten[0].Name != "Running" {
+		errorExpGot(t, "[Running]", gotten, false)
+	}
+
+	if _, statErr := os.Stat(rnr.sessionFile(ended.Process.Pid)); !os.IsNotExist(statErr) {
+		errorExpGot(t, "ended session forgotten", statErr, true)
+	}
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+
+	// stopping it ends it in time
+	var stopErr = rnr.stopSession(gotten[0], 2*time.Second)
+
+	if stopErr != nil {
+		errorExpGot(t, nil, stopErr, true)
+	}
+
+	if left, _ := rnr.liveSessions(); len(left) != 0 {
+		errorExpGot(t, "[]", left, false)
+	}
+}
+
+func TestWineserverProgram(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamProgram string
+	}{
+		{
+			Description:  "wine in the path",
+			Expected:     "wineserver",
+			ParamProgram: "wine",
+		},
+		{
+			Description:  "wine in a dir",
+			Expected:     "/opt/wine-staging/bin/wineserver",
+			ParamProgram: "/opt/wine-staging/bin/wine64",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = Runner{Program: testCase.ParamProgram}.wineserverProgram()
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// a command as it would be run for an exe, with the
+// variables winela adds to the environment
+type LaunchCommand struct {
+	Argv []string          `json:"argv"`
+	Dir  string            `json:"dir"`
+	Env  map[string]string `json:"env"`
+}
+
+// make up the command an exe of the list would be run with,
+// without running it or changing anything in its prefix
+func (r Runner) launchCommand(elementNumber int) (ret LaunchCommand, retErr error) {
+	var targetExe, findErr = r.exeByNumber(elementNumber)
+	if findErr != nil {
+		return ret, findErr
+	}
+
+	var prefixConfig, configErr = readPrefixConfig(r.prefixPath(targetExe.Prefix))
+	if configErr != nil {
+		return ret, configErr
+	}
+
+	return newLaunchCommand(r.commandArgs(targetExe), r.commandEnv(targetExe, prefixConfig)), nil
+}
+
+// make up a command run where winela is run from its arguments
+// and the variables added to the environment
+func newLaunchCommand(argv []string, env []string) (ret LaunchCommand) {
+	ret.Dir, _ = os.Getwd()
+	ret.Argv = argv
+	ret.Env = make(map[string]string)
+
+	for _, variable := range env {
+		var pair = strings.SplitN(variable, "=", 2)
+		if len(pair) == 2 {
+			ret.Env[pair[0]] = pair[1]
+		}
+	}
+
+	return
+}
+
+// get the variables added to the environment as they are given
+// to a command, by their names
+func (c LaunchCommand) envList() (retList []string) {
+	var names []string
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		retList = append(retList, name+"="+c.Env[name])
+	}
+	return
+}
+
+// quote a word for a shell if it needs it
+func shellQuote(word string) string {
+	if word != "" && strings.Trim(word, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,+@%") == "" {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// write the command as a line that can be pasted into a shell
+func (c LaunchCommand) shellLine() string {
+	var words = []string{"cd", shellQuote(c.Dir), "&&"}
+
+	var names []string
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		words = append(words, name+"="+shellQuote(c.Env[name]))
+	}
+
+	for _, arg := range c.Argv {
+		words = append(words, shellQuote(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// write the command as json
+func (c LaunchCommand) jsonLine() string {
+	var data, _ = json.Marshal(c)
+	return string(data)
+}
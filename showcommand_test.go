@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamWord string
+	}{
+		{
+			Description: "plain word",
+			Expected:    "/games/Game.exe",
+			ParamWord:   "/games/Game.exe",
+		},
+		{
+			Description: "word with spaces",
+			Expected:    "'/games/My Game.exe'",
+			ParamWord:   "/games/My Game.exe",
+		},
+		{
+			Description: "word with a quote",
+			Expected:    `'it'\''s'`,
+			ParamWord:   "it's",
+		},
+		{
+			Description: "empty word",
+			Expected:    "''",
+			ParamWord:   "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = shellQuote(testCase.ParamWord)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestLaunchCommand(t *testing.T) {
+	var cwd, _ = os.Getwd()
+
+	var rnr = Runner{
+		Program:   "wine",
+		PrefixDir: "/prefixes",
+		Dlls:      []DllOverride{{Dll: "d3d9", Order: "n"}},
+		List: []Exe{
+			{Name: "Game", Path: "/games/My Game.exe", Prefix: "games", Desktop: "800x600"},
+		},
+	}
+
+	var gotten, gottenErr = rnr.launchCommand(1)
+
+	var expectedShell = fmt.Sprintf("cd %s && WINEDLLOVERRIDES=d3d9=n WINEPREFIX=/prefixes/games "+
+		"wine explorer '/desktop=My Game,800x600' start /wait /unix '/games/My Game.exe'", shellQuote(cwd))
+	if gotten.shellLine() != expectedShell {
+		errorExpGot(t, expectedShell, gotten.shellLine(), false)
+	}
+
+	var expectedJSON = fmt.Sprintf(`{"argv":["wine","explorer","/desktop=My Game,800x600","start","/wait","/unix","/games/My Game.exe"],"dir":%q,`+
+		`"env":{"WINEDLLOVERRIDES":"d3d9=n","WINEPREFIX":"/prefixes/games"}}`, cwd)
+	if gotten.jsonLine() != expectedJSON {
+		errorExpGot(t, expectedJSON, gotten.jsonLine(), false)
+	}
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+}
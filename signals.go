@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// a process run from the list, with the log of it for forked
+// ones and how it ended for ones run without forking
+type RunResult struct {
+	Pid      int
+	Log      string
+	ExitCode int
+	Signal   syscall.Signal
+	Duration time.Duration
+}
+
+// get how a finished command ended, by exit code
+// or by the signal that killed it
+func commandResult(commandToRun *exec.Cmd, startTime time.Time) (ret RunResult) {
+	ret.Duration = time.Since(startTime)
+
+	if commandToRun.ProcessState == nil {
+		return
+	}
+
+	if status, isWaitStatus := commandToRun.ProcessState.Sys().(syscall.WaitStatus); isWaitStatus && status.Signaled() {
+		ret.Signal = status.Signal()
+		return
+	}
+
+	ret.ExitCode = commandToRun.ProcessState.ExitCode()
+	return
+}
+
+// get the exit code winela ends with for a process, its exit
+// code or 128 and the number of the signal that killed it
+// the way shells have it
+func (res RunResult) code() int {
+	if res.Signal != 0 {
+		return 128 + int(res.Signal)
+	}
+	return res.ExitCode
+}
+
+// describe how a process ended and how long it ran
+func (res RunResult) String() string {
+	var ran = res.Duration.Round(time.Second)
+	if res.Signal != 0 {
+		return fmt.Sprintf("after %v, killed by signal %d (%s)", ran, int(res.Signal), res.Signal.String())
+	}
+	return fmt.Sprintf("after %v with exit code %d", ran, res.ExitCode)
+}
+
+// pass the signals ending winela (ctrl+c, kill, closing the terminal)
+// on to the process group of a process, until stopped
+func forwardSignals(pid int) (stop func()) {
+	var signals = make(chan os.Signal, 1)
+	var done = make(chan bool)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				syscall.Kill(-pid, sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// get the process group in the foreground of the terminal
+// winela reads from, if it reads from one
+func terminalGroup() (ret int, isTerminal bool) {
+	var group int32
+	var _, _, errno = syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&group)))
+	return int(group), errno == 0
+}
+
+// run a command in a process group of its own, which is put in the
+// foreground of the terminal when winela is so that it can still read
+// from it, returning what gives the terminal back once it ended
+func ownGroup(commandToRun *exec.Cmd) (giveBack func()) {
+	var group, isTerminal = terminalGroup()
+	if !isTerminal || group != syscall.Getpgrp() {
+		commandToRun.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		return func() {}
+	}
+
+	commandToRun.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: int(os.Stdin.Fd())}
+	return func() {
+		// winela is in the background until then, which
+		// stops it for changing the terminal by default
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+
+		var ownGroup = int32(group)
+		syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&ownGroup)))
+	}
+}
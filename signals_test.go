@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunFromListResult(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var testTable = []struct {
+		Description  string
+		Expected     int
+		ExpectedText string
+
+		ParamScript string
+	}{
+		{
+			Description:  "process exiting fine",
+			Expected:     0,
+			ExpectedText: "after 0s with exit code 0",
+			ParamScript:  "exit 0",
+		},
+		{
+			Description:  "process exiting with an error",
+			Expected:     7,
+			ExpectedText: "after 0s with exit code 7",
+			ParamScript:  "exit 7",
+		},
+		{
+			Description:  "process killed",
+			Expected:     128 + 15,
+			ExpectedText: "after 0s, killed by signal 15 (terminated)",
+			ParamScript:  "kill -TERM $$",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			// the script stands in for the exe
+			var rnr = Runner{
+				Program:     "sh",
+				ProgramArgs: "-c",
+				List: []Exe{
+					{Name: "Script", Path: testCase.ParamScript},
+				},
+			}
+
+			var gotten, gottenErr = rnr.runFromList(1, false)
+
+			if testCase.Expected != gotten.code() || testCase.ExpectedText != gotten.String() {
+				errorExpGot(t, testCase.ExpectedText, gotten, false)
+			}
+
+			if gottenErr != nil {
+				errorExpGot(t, nil, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestRunResultCode(t *testing.T) {
+	var gotten = RunResult{Signal: syscall.SIGINT, Duration: 90 * time.Second}
+
+	if gotten.code() != 130 || gotten.String() != "after 1m30s, killed by signal 2 (interrupt)" {
+		errorExpGot(t, "130 after 1m30s, killed by signal 2 (interrupt)", gotten, false)
+	}
+}
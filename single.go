@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// what a program is run alone in, nothing (off), its entry
+// (one session of it at a time) or its prefix (no other entry
+// running in the prefix while it does)
+var singlePolicies = []string{
+	"off", "entry", "prefix",
+}
+
+// check if a single instance policy is known
+func isSinglePolicy(value string) bool {
+	for _, policy := range singlePolicies {
+		if value == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// get the single instance policy of an exe, its own before that of winelarc
+func (r Runner) singlePolicy(targetExe Exe) string {
+	switch {
+	case targetExe.Single != "":
+		return targetExe.Single
+	case r.Single != "":
+		return r.Single
+	default:
+		return "off"
+	}
+}
+
+// a launch refused as what it needs is held by another session,
+// which only has a pid while it is still being started
+type lockedError struct {
+	Session Session
+	Prefix  bool
+}
+
+func (e lockedError) Error() string {
+	var holder = fmt.Sprintf("%s (entry %d, pid %d)", e.Session.Name, e.Session.Entry, e.Session.Pid)
+	if e.Session.Name == "" {
+		holder = fmt.Sprintf("a launch being started (pid %d)", e.Session.Pid)
+	}
+
+	if e.Prefix {
+		return fmt.Sprintf("prefix %s is in use by %s", e.Session.Prefix, holder)
+	}
+	return fmt.Sprintf("already running as %s", holder)
+}
+
+// get the dir the locks of running programs are kept in
+func (r Runner) locksDir() string {
+	return path.Join(r.StateDir, "locks")
+}
+
+// get the lock file of an entry or a prefix, named by a hash
+// of its path as paths can't be file names
+func (r Runner) lockFile(kind string, target string) string {
+	var hash = fmt.Sprintf("%x", sha256.Sum256([]byte(target)))
+	return path.Join(r.locksDir(), kind+"-"+hash[:16])
+}
+
+// check if the process or process group of a pid is still there
+func lockAlive(pid int) bool {
+	for _, target := range []int{-pid, pid} {
+		if killErr := syscall.Kill(target, 0); killErr == nil || killErr == syscall.EPERM {
+			return true
+		}
+	}
+	return false
+}
+
+// read the pid holding a lock and whether it still does, a lock with
+// no pid yet is held only while it is new as it is being written
+func readLock(lock string) (pid int, held bool) {
+	var data, readErr = ioutil.ReadFile(lock)
+	if readErr != nil {
+		return 0, false
+	}
+
+	var convErr error
+	pid, convErr = strconv.Atoi(strings.TrimSpace(string(data)))
+	if convErr != nil {
+		var info, statErr = os.Stat(lock)
+		return 0, statErr == nil && time.Since(info.ModTime()) < time.Minute
+	}
+
+	return pid, lockAlive(pid)
+}
+
+// get the error of a lock being held by a pid, with
+// the session of the pid if it is recorded yet
+func (r Runner) lockedBy(pid int, prefix string) lockedError {
+	var s, readErr = readSession(r.sessionFile(pid))
+	if readErr != nil {
+		s = Session{Pid: pid, Prefix: prefix}
+	}
+	return lockedError{Session: s, Prefix: prefix != ""}
+}
+
+// take a lock for winela launching a program, taking over
+// a lock left by a session that did not end cleanly
+func (r Runner) takeLock(lock string, prefix string) error {
+	if mkdirErr := os.MkdirAll(r.locksDir(), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	for tries := 0; tries < 2; tries++ {
+		var file, createErr = os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if createErr == nil {
+			var _, writeErr = file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			return writeErr
+		} else if !os.IsExist(createErr) {
+			return createErr
+		}
+
+		if pid, held := readLock(lock); held {
+			return r.lockedBy(pid, prefix)
+		}
+		os.Remove(lock)
+	}
+
+	return fmt.Errorf("could not take lock %s", lock)
+}
+
+// take the locks a launch of an exe needs by its policy, refusing it
+// while another session holds them, and check the ones it does not
+// need are not held by anything else either, returning the ones taken
+func (r Runner) takeLocks(targetExe Exe) (retList []string, retErr error) {
+	var policy = r.singlePolicy(targetExe)
+
+	// without a state dir there are no sessions to lock for
+	if r.StateDir == "" {
+		if policy != "off" {
+			return nil, fmt.Errorf("single instances need a state dir to keep track of sessions")
+		}
+		return nil, nil
+	}
+
+	var prefixDir = r.prefixPath(targetExe.Prefix)
+
+	// a program run alone in its prefix waits for the others in it
+	if policy == "prefix" {
+		var sessions, sessionsErr = r.liveSessions()
+		if sessionsErr != nil {
+			return nil, sessionsErr
+		}
+		for _, s := range sessions {
+			if s.Prefix == prefixDir {
+				return nil, lockedError{Session: s, Prefix: true}
+			}
+		}
+	}
+
+	var locks = []struct {
+		File   string
+		Prefix string
+		Wanted bool
+	}{
+		{r.lockFile("entry", targetExe.Path), "", policy != "off"},
+		{r.lockFile("prefix", prefixDir), prefixDir, policy == "prefix"},
+	}
+	for _, lock := range locks {
+		if !lock.Wanted {
+			if pid, held := readLock(lock.File); held {
+				removeLocks(retList)
+				return nil, r.lockedBy(pid, lock.Prefix)
+			}
+			continue
+		}
+
+		if takeErr := r.takeLock(lock.File, lock.Prefix); takeErr != nil {
+			removeLocks(retList)
+			return nil, takeErr
+		}
+		retList = append(retList, lock.File)
+	}
+
+	return
+}
+
+// hand locks taken by winela over to the session it started
+func tieLocks(locks []string, pid int) {
+	for _, lock := range locks {
+		ioutil.WriteFile(lock, []byte(strconv.Itoa(pid)), 0644)
+	}
+}
+
+// remove locks
+func removeLocks(locks []string) {
+	for _, lock := range locks {
+		os.Remove(lock)
+	}
+}
+
+// remove the locks held by the session of a pid
+func (r Runner) releaseLocks(pid int) {
+	var entries, _ = ioutil.ReadDir(r.locksDir())
+	for _, entry := range entries {
+		var lock = path.Join(r.locksDir(), entry.Name())
+		var data, _ = ioutil.ReadFile(lock)
+		if strings.TrimSpace(string(data)) == strconv.Itoa(pid) {
+			os.Remove(lock)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTakeLocks(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// a sleep stands in for a session still running
+	// and a process that is done for one that ended
+	var live = exec.Command("sleep", "5")
+	live.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	live.Start()
+	defer live.Process.Kill()
+	var livePid = live.Process.Pid
+	var ended = exec.Command("true")
+	ended.Run()
+	var deadPid = ended.Process.Pid
+
+	var rnr = Runner{StateDir: inTestDir("state")}
+	var game = Exe{Name: "Game", Path: "/games/Game.exe", Prefix: "/prefixes/games"}
+	var other = Exe{Name: "Other", Path: "/games/Other.exe", Prefix: "/prefixes/games"}
+	var running = Session{Entry: 1, Name: "Game", Path: game.Path, Pid: livePid, Prefix: "/prefixes/games", Started: time.Now()}
+
+	var testTable = []struct {
+		Description string
+		Expected    int
+		ExpectedErr error
+
+		ParamExe    Exe
+		ParamSingle string
+		ParamLocks  map[string]int
+		ParamLive   bool
+	}{
+		{
+			Description: "entry running already",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf("already running as Game (entry 1, pid %d)", livePid),
+			ParamExe:    game,
+			ParamSingle: "entry",
+			ParamLocks:  map[string]int{rnr.lockFile("entry", game.Path): livePid},
+			ParamLive:   true,
+		},
+		{
+			Description: "lock left by a session that ended",
+			Expected:    1,
+			ExpectedErr: nil,
+			ParamExe:    game,
+			ParamSingle: "entry",
+			ParamLocks:  map[string]int{rnr.lockFile("entry", game.Path): deadPid},
+		},
+		{
+			Description: "other entry running in the prefix",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf("prefix /prefixes/games is in use by Game (entry 1, pid %d)", livePid),
+			ParamExe:    other,
+			ParamSingle: "prefix",
+			ParamLive:   true,
+		},
+		{
+			Description: "prefix held by an entry run alone in it",
+			Expected:    0,
+			ExpectedErr: fmt.Errorf("prefix /prefixes/games is in use by Game (entry 1, pid %d)", livePid),
+			ParamExe:    other,
+			ParamSingle: "",
+			ParamLocks:  map[string]int{rnr.lockFile("prefix", "/prefixes/games"): livePid},
+			ParamLive:   true,
+		},
+		{
+			Description: "entry and prefix free",
+			Expected:    2,
+			ExpectedErr: nil,
+			ParamExe:    game,
+			ParamSingle: "prefix",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.RemoveAll(rnr.StateDir)
+			os.MkdirAll(rnr.locksDir(), 0755)
+			for lock, pid := range testCase.ParamLocks {
+				ioutil.WriteFile(lock, []byte(strconv.Itoa(pid)), 0644)
+			}
+			if testCase.ParamLive {
+				rnr.writeSession(running)
+			}
+
+			var testRunner = rnr
+			testRunner.Single = testCase.ParamSingle
+			var gotten, gottenErr = testRunner.takeLocks(testCase.ParamExe)
+
+			if testCase.Expected != len(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+
+	t.Run("single instance with no state dir", func(t *testing.T) {
+		var _, gottenErr = Runner{Single: "entry"}.takeLocks(game)
+
+		var expectedErr = fmt.Errorf("single instances need a state dir to keep track of sessions")
+		if equalErrorList(t, []error{expectedErr}, []error{gottenErr}) == false {
+			errorExpGot(t, expectedErr, gottenErr, true)
+		}
+	})
+}
+
+func TestRunFromListSingle(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	// the script stands in for the exe
+	var rnr = Runner{
+		Program:     "sh",
+		ProgramArgs: "-c",
+		StateDir:    inTestDir("state"),
+		List: []Exe{
+			{Name: "Script", Path: "sleep 5", Single: "entry"},
+		},
+	}
+
+	var first, firstErr = rnr.runFromList(1, true)
+	if firstErr != nil {
+		errorExpGot(t, nil, firstErr, true)
+	}
+
+	// pressed again while it runs
+	var _, secondErr = rnr.runFromList(1, true)
+	if locked, isLocked := secondErr.(lockedError); !isLocked || locked.Session.Pid != first.Pid {
+		errorExpGot(t, lockedError{Session: Session{Pid: first.Pid}}, secondErr, true)
+	}
+
+	// and the lock goes with the session
+	var session, _ = readSession(rnr.sessionFile(first.Pid))
+	rnr.stopSession(session, time.Second)
+	if locks, _ := ioutil.ReadDir(rnr.locksDir()); len(locks) != 0 {
+		errorExpGot(t, 0, len(locks), false)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a launch of an exe from the list that ended
+type Launch struct {
+	Started  time.Time
+	Duration time.Duration
+	Name     string
+	Path     string
+}
+
+// how much an exe was used
+type ExeStats struct {
+	Launches int
+	Runtime  time.Duration
+	Last     time.Time
+}
+
+// get the file the history of launches is kept in
+func (r Runner) historyFile() string {
+	return path.Join(r.StateDir, "history")
+}
+
+// write a launch the way it is kept in the history, when it
+// started, how many seconds it ran and the name and path of the exe
+func (l Launch) String() string {
+	return fmt.Sprintf("%s %d %s => %s", l.Started.Format(time.RFC3339), int64(l.Duration.Seconds()), l.Name, l.Path)
+}
+
+// read a launch as kept in the history
+func parseLaunch(line string) (ret Launch, retErr error) {
+	var pair = strings.SplitN(line, "=>", 2)
+	var fields = strings.SplitN(strings.TrimSpace(pair[0]), " ", 3)
+	if len(pair) != 2 || len(fields) != 3 {
+		return ret, fmt.Errorf("launch %q: needs a time, seconds, a name and a path", line)
+	}
+
+	var startErr, secondsErr error
+	var seconds int64
+	ret.Started, startErr = time.Parse(time.RFC3339, fields[0])
+	seconds, secondsErr = strconv.ParseInt(fields[1], 10, 64)
+	if startErr != nil || secondsErr != nil {
+		return ret, fmt.Errorf("launch %q: needs a time, seconds, a name and a path", line)
+	}
+
+	ret.Duration = time.Duration(seconds) * time.Second
+	ret.Name = strings.TrimSpace(fields[2])
+	ret.Path = strings.TrimSpace(pair[1])
+	return
+}
+
+// add a launch to the history
+func (r Runner) recordLaunch(l Launch) error {
+	if r.StateDir == "" {
+		return nil
+	}
+	os.MkdirAll(r.StateDir, 0755)
+
+	var historyFile, openErr = os.OpenFile(r.historyFile(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if openErr != nil {
+		return openErr
+	}
+	defer historyFile.Close()
+
+	var _, writeErr = historyFile.WriteString(l.String() + "\n")
+	return writeErr
+}
+
+// read the history of launches, leaving out
+// lines that can't be read and launches before since
+func (r Runner) readHistory(since time.Time) (retList []Launch, retErr error) {
+	var readData, readErr = ioutil.ReadFile(r.historyFile())
+	if os.IsNotExist(readErr) {
+		return nil, nil
+	} else if readErr != nil {
+		return nil, readErr
+	}
+
+	for _, line := range strings.Split(string(readData), "\n") {
+		var l, parseErr = parseLaunch(line)
+		if parseErr != nil || l.Started.Before(since) {
+			continue
+		}
+		retList = append(retList, l)
+	}
+
+	return
+}
+
+// get how much each exe was used by its path
+func statsByPath(history []Launch) map[string]ExeStats {
+	var ret = make(map[string]ExeStats)
+
+	for _, l := range history {
+		var s = ret[l.Path]
+		s.Launches++
+		s.Runtime += l.Duration
+		if l.Started.After(s.Last) {
+			s.Last = l.Started
+		}
+		ret[l.Path] = s
+	}
+
+	return ret
+}
+
+// write a duration in hours and minutes
+func formatRuntime(d time.Duration) string {
+	var minutes = int64(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// return the list as a numbered string with how much each exe was
+// used, sorted by the latest launched (recent) or the most launched
+// (used) when asked to, keeping the numbers exes have in the list
+func (r Runner) displayListStats(stats map[string]ExeStats, sortBy string) (ret string) {
+	var order []int
+	for index := range r.List {
+		order = append(order, index)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		var a, b = stats[r.List[order[i]].Path], stats[r.List[order[j]].Path]
+		switch sortBy {
+		case "recent":
+			return a.Last.After(b.Last)
+		case "used":
+			return a.Launches > b.Launches || (a.Launches == b.Launches && a.Runtime > b.Runtime)
+		}
+		return false
+	})
+
+	for _, index := range order {
+		var entry = r.List[index]
+		var s = stats[entry.Path]
+
+		var last = "never"
+		if !s.Last.IsZero() {
+			last = s.Last.Local().Format("2006-01-02 15:04")
+		}
+
+		ret += fmt.Sprintf("%v %v (%v launches, %s, last %s)\n", index+1, entry.Name, s.Launches, formatRuntime(s.Runtime), last)
+	}
+
+	return
+}
+
+// a row of the stats, the use of an exe on a day or in total
+type statsRow struct {
+	Day  string
+	Name string
+	Path string
+	ExeStats
+}
+
+// get the use of each exe in total, by most used first,
+// and on each day, by day and most used first
+func statsRows(history []Launch) (totals []statsRow, days []statsRow) {
+	for exePath, s := range statsByPath(history) {
+		totals = append(totals, statsRow{Day: "total", Path: exePath, ExeStats: s})
+	}
+
+	var byDay = make(map[string][]Launch)
+	for _, l := range history {
+		var day = l.Started.Local().Format("2006-01-02")
+		byDay[day] = append(byDay[day], l)
+	}
+	for day, launches := range byDay {
+		for exePath, s := range statsByPath(launches) {
+			days = append(days, statsRow{Day: day, Path: exePath, ExeStats: s})
+		}
+	}
+
+	// names as they were last launched by
+	var names = make(map[string]string)
+	for _, l := range history {
+		names[l.Path] = l.Name
+	}
+	for _, rows := range [][]statsRow{totals, days} {
+		for i := range rows {
+			rows[i].Name = names[rows[i].Path]
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Day != rows[j].Day {
+				return rows[i].Day < rows[j].Day
+			}
+			if rows[i].Runtime != rows[j].Runtime {
+				return rows[i].Runtime > rows[j].Runtime
+			}
+			return rows[i].Path < rows[j].Path
+		})
+	}
+
+	return
+}
+
+// return the stats of launches as lines of text
+func displayStats(history []Launch) (ret string) {
+	var totals, days = statsRows(history)
+
+	for _, row := range totals {
+		ret += fmt.Sprintf("%v: %v launches, %s, last %s\n",
+			row.Name, row.Launches, formatRuntime(row.Runtime), row.Last.Local().Format("2006-01-02 15:04"))
+	}
+
+	for _, row := range days {
+		ret += fmt.Sprintf("%v %v: %v launches, %s\n", row.Day, row.Name, row.Launches, formatRuntime(row.Runtime))
+	}
+
+	return
+}
+
+// quote a field of a csv line if it needs it
+func csvField(value string) string {
+	if strings.ContainsAny(value, ",\"\n") {
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+	return value
+}
+
+// return the stats of launches as csv, the totals having total as day
+func displayStatsCSV(history []Launch) (ret string) {
+	var totals, days = statsRows(history)
+
+	ret = "day,name,path,launches,seconds\n"
+	for _, row := range append(totals, days...) {
+		ret += strings.Join([]string{
+			row.Day, csvField(row.Name), csvField(row.Path),
+			strconv.Itoa(row.Launches), strconv.FormatInt(int64(row.Runtime.Seconds()), 10),
+		}, ",") + "\n"
+	}
+
+	return
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// make a history of launches of two exes over two days
+func makeTestHistory() []Launch {
+	var day1 = time.Date(2026, 10, 18, 20, 0, 0, 0, time.Local)
+	var day2 = time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+
+	return []Launch{
+		{Started: day1, Duration: 90 * time.Minute, Name: "Game", Path: "/games/Game.exe"},
+		{Started: day1.Add(2 * time.Hour), Duration: 10 * time.Minute, Name: "Editor, Pro", Path: "/apps/Editor.exe"},
+		{Started: day2, Duration: 30 * time.Minute, Name: "Game", Path: "/games/Game.exe"},
+	}
+}
+
+func TestParseLaunch(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    Launch
+		ExpectedErr error
+
+		ParamLine string
+	}{
+		{
+			Description: "launch as written",
+			Expected:    makeTestHistory()[1],
+			ExpectedErr: nil,
+			ParamLine:   makeTestHistory()[1].String(),
+		},
+		{
+			Description: "no path",
+			Expected:    Launch{},
+			ExpectedErr: fmt.Errorf(`launch "2026-10-19T09:30:00Z 60 Game": needs a time, seconds, a name and a path`),
+			ParamLine:   "2026-10-19T09:30:00Z 60 Game",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = parseLaunch(testCase.ParamLine)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) && gottenErr == nil {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestRecordReadHistory(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{StateDir: TestDir}
+	var history = makeTestHistory()
+	for _, l := range history {
+		rnr.recordLaunch(l)
+	}
+
+	var gotten, gottenErr = rnr.readHistory(time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local))
+
+	if fmt.Sprint(history[2:]) != fmt.Sprint(gotten) {
+		errorExpGot(t, history[2:], gotten, false)
+	}
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+}
+
+func TestDisplayListStats(t *testing.T) {
+	var rnr = Runner{
+		List: []Exe{
+			{Name: "Editor, Pro", Path: "/apps/Editor.exe"},
+			{Name: "Never", Path: "/apps/Never.exe"},
+			{Name: "Game", Path: "/games/Game.exe"},
+		},
+	}
+	var stats = statsByPath(makeTestHistory())
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamSort string
+	}{
+		{
+			Description: "in list order",
+			Expected: "1 Editor, Pro (1 launches, 10m, last 2026-10-18 22:00)\n" +
+				"2 Never (0 launches, 0m, last never)\n" +
+				"3 Game (2 launches, 2h00m, last 2026-10-19 09:30)\n",
+			ParamSort: "",
+		},
+		{
+			Description: "most used first",
+			Expected: "3 Game (2 launches, 2h00m, last 2026-10-19 09:30)\n" +
+				"1 Editor, Pro (1 launches, 10m, last 2026-10-18 22:00)\n" +
+				"2 Never (0 launches, 0m, last never)\n",
+			ParamSort: "used",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = rnr.displayListStats(stats, testCase.ParamSort)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestDisplayStats(t *testing.T) {
+	var expected = "Game: 2 launches, 2h00m, last 2026-10-19 09:30\n" +
+		"Editor, Pro: 1 launches, 10m, last 2026-10-18 22:00\n" +
+		"2026-10-18 Game: 1 launches, 1h30m\n" +
+		"2026-10-18 Editor, Pro: 1 launches, 10m\n" +
+		"2026-10-19 Game: 1 launches, 30m\n"
+	if gotten := displayStats(makeTestHistory()); expected != gotten {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	var expectedCSV = "day,name,path,launches,seconds\n" +
+		"total,Game,/games/Game.exe,2,7200\n" +
+		"total,\"Editor, Pro\",/apps/Editor.exe,1,600\n" +
+		"2026-10-18,Game,/games/Game.exe,1,5400\n" +
+		"2026-10-18,\"Editor, Pro\",/apps/Editor.exe,1,600\n" +
+		"2026-10-19,Game,/games/Game.exe,1,1800\n"
+	if gotten := displayStatsCSV(makeTestHistory()); expectedCSV != gotten {
+		errorExpGot(t, expectedCSV, gotten, false)
+	}
+}
+
+func TestEndSession(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{StateDir: TestDir}
+	var started = time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	var session = Session{Entry: 1, Name: "Game", Path: "/games/Game.exe", Pid: 4242, Started: started, Log: "game.log"}
+	rnr.writeSession(session)
+
+	// ended twice but recorded once
+	rnr.endSessionOfLog("game.log", started.Add(time.Hour))
+	rnr.endSession(session, started.Add(2*time.Hour))
+
+	var gotten, _ = rnr.readHistory(time.Time{})
+	var expected = []Launch{{Started: started, Duration: time.Hour, Name: "Game", Path: "/games/Game.exe"}}
+
+	if fmt.Sprint(expected) != fmt.Sprint(gotten) {
+		errorExpGot(t, expected, gotten, false)
+	}
+}
@@ -32,6 +32,8 @@ func equalExeList(t *testing.T, listA []Exe, listB []Exe) (equal bool) {
 			return false
 		} else if listA[i].Path != listB[i].Path {
 			return false
+		} else if listA[i].attributeLines() != listB[i].attributeLines() {
+			return false
 		}
 	}
 	return true
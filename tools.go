@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+// the programs wine comes with that can be run by their name
+var wineTools = []string{
+	"winecfg", "regedit", "taskmgr", "control", "cmd", "explorer", "wineboot",
+	"winefile", "notepad", "uninstaller", "reg", "regsvr32", "rundll32", "start",
+	"msiexec", "wineconsole", "winedbg", "winver", "wordpad", "iexplore",
+	"oleview", "progman", "tasklist", "taskkill", "ipconfig", "wmic", "winemine",
+}
+
+// check if a command is to be run by wine, which is the case for
+// the tools wine comes with, windows paths and windows programs
+func isWineTool(command string) bool {
+	var lowered = strings.ToLower(command)
+	for _, tool := range wineTools {
+		if lowered == tool {
+			return true
+		}
+	}
+
+	if isWindowsPath(command) {
+		return true
+	}
+
+	switch strings.ToLower(path.Ext(command)) {
+	case ".exe", ".msi", ".bat", ".cmd", ".lnk":
+		return true
+	}
+
+	return false
+}
+
+// make up the arguments of a command run in the context of
+// an exe, going through wine for wine programs and run as
+// it is for commands of the host
+func (r Runner) toolArgs(toolArgs []string) (retList []string) {
+	if !isWineTool(toolArgs[0]) {
+		return toolArgs
+	}
+
+	retList = append(retList, r.Program)
+	if r.ProgramArgs != "" {
+		retList = append(retList, r.ProgramArgs)
+	}
+	return append(retList, toolArgs...)
+}
+
+// run a wine program or host command with the runner,
+// environment and prefix an exe of the list runs with, next to
+// it running or not, kept to the time it has left without being
+// counted as a launch of it, returns how it ended
+func (r Runner) execInContext(elementNumber int, toolArgs []string) (ret RunResult, retErr error) {
+	var targetExe, findErr = r.exeByNumber(elementNumber)
+	if findErr != nil {
+		return ret, findErr
+	}
+
+	var left, limited, admitErr = r.admitTime(targetExe)
+	if admitErr != nil {
+		return ret, admitErr
+	}
+
+	// the exe the context is of is checked the way launching it would
+	if verifyErr := r.verifyBeforeLaunch(targetExe, nil, os.Stderr); verifyErr != nil {
+		return ret, verifyErr
+	}
+
+	var prefixConfig, restorePrefix, prepareErr = r.preparePrefix(targetExe, true)
+	if prepareErr != nil {
+		return ret, prepareErr
+	}
+	defer restorePrefix()
+
+	var commandArgs = r.toolArgs(toolArgs)
+	var commandToRun = r.makeCommand(commandArgs, r.commandEnv(targetExe, prefixConfig))
+	commandToRun.Stdin = os.Stdin
+	commandToRun.Stdout = os.Stdout
+	commandToRun.Stderr = os.Stderr
+
+	// in a process group of its own to be stopped with
+	// what it started once out of time
+	var giveBack = ownGroup(commandToRun)
+	var startTime = time.Now()
+	if startErr := commandToRun.Start(); startErr != nil {
+		giveBack()
+		return ret, fmt.Errorf("could not execute %s: %s", commandArgs[0], startErr.Error())
+	}
+	var stopForwarding = forwardSignals(commandToRun.Process.Pid)
+	defer stopForwarding()
+
+	// stopped once out of time, as a session that is not recorded
+	if limited {
+		var limitDone = make(chan bool)
+		defer close(limitDone)
+		var session = r.newSession(elementNumber, targetExe, RunResult{Pid: commandToRun.Process.Pid}, startTime)
+		go r.enforceLimit(session, left, r.hooks(targetExe, "LimitWarning"), nil, os.Stderr, limitDone)
+	}
+
+	// an exit code or a signal ending it is not an error of winela
+	var waitErr = commandToRun.Wait()
+	giveBack()
+	ret = commandResult(commandToRun, startTime)
+	ret.Pid = commandToRun.Process.Pid
+	if _, isExitErr := waitErr.(*exec.ExitError); waitErr != nil && !isExitErr {
+		return ret, fmt.Errorf("could not wait for %s: %s", commandArgs[0], waitErr.Error())
+	}
+
+	return
+}
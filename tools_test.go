@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestToolArgs(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamRunner Runner
+		ParamArgs   []string
+	}{
+		{
+			Description: "wine tool",
+			Expected:    []string{"wine", "winecfg"},
+			ParamRunner: Runner{Program: "wine"},
+			ParamArgs:   []string{"winecfg"},
+		},
+		{
+			Description: "windows program with arguments and program arguments",
+			Expected:    []string{"proton", "run", `C:\setup.exe`, "/S"},
+			ParamRunner: Runner{Program: "proton", ProgramArgs: "run"},
+			ParamArgs:   []string{`C:\setup.exe`, "/S"},
+		},
+		{
+			Description: "host command",
+			Expected:    []string{"wineserver", "-k"},
+			ParamRunner: Runner{Program: "wine"},
+			ParamArgs:   []string{"wineserver", "-k"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamRunner.toolArgs(testCase.ParamArgs)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestExecInContext(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var rnr = Runner{
+		Program:  "sh",
+		StateDir: inTestDir("state"),
+		List: []Exe{
+			{Name: "Game", Path: "/games/game.exe", Single: "entry"},
+			{Name: "Limited", Path: "/games/limited.exe", Limit: "2s"},
+		},
+	}
+
+	t.Run("not counted as a launch of the entry", func(t *testing.T) {
+		var _, gottenErr = rnr.execInContext(1, []string{"true"})
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var history, _ = rnr.readHistory(time.Time{})
+		var sessions, _ = rnr.liveSessions()
+		if len(history) != 0 || len(sessions) != 0 {
+			errorExpGot(t, "no launch and no session", []interface{}{history, sessions}, false)
+		}
+	})
+
+	t.Run("exit code of the command", func(t *testing.T) {
+		var gotten, gottenErr = rnr.execInContext(1, []string{"sh", "-c", "exit 7"})
+
+		if gotten.code() != 7 {
+			errorExpGot(t, 7, gotten.code(), false)
+		}
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+	})
+
+	t.Run("run next to the entry running alone", func(t *testing.T) {
+		var lockErr = rnr.takeLock(rnr.lockFile("entry", "/games/game.exe"), "")
+		if lockErr != nil {
+			errorExpGot(t, nil, lockErr, true)
+			return
+		}
+		defer os.Remove(rnr.lockFile("entry", "/games/game.exe"))
+
+		if _, gottenErr := rnr.execInContext(1, []string{"true"}); gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		if _, statErr := os.Stat(rnr.lockFile("entry", "/games/game.exe")); statErr != nil {
+			errorExpGot(t, "the lock of the entry kept", statErr, true)
+		}
+	})
+
+	t.Run("stopped once out of time", func(t *testing.T) {
+		var gotten, gottenErr = rnr.execInContext(2, []string{"sleep", "5"})
+
+		if gotten.code() != 128+15 {
+			errorExpGot(t, "killed by signal 15", gotten, false)
+		}
+
+		if gottenErr != nil {
+			errorExpGot(t, nil, gottenErr, true)
+		}
+
+		var history, _ = rnr.readHistory(time.Time{})
+		if len(history) != 0 {
+			errorExpGot(t, "no launch", history, false)
+		}
+	})
+}
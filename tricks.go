@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// get the winetricks program to run, which is
+// found in the path unless set in the config
+func (r Runner) winetricksProgram() string {
+	if r.Winetricks == "" {
+		return "winetricks"
+	}
+	return r.Winetricks
+}
+
+// make up the command running winetricks with verbs in the prefix
+// of an exe, unattended and using the same wine and environment
+// (dll overrides included) as the exe is run with
+func (r Runner) winetricksCommand(targetExe Exe, prefixConfig PrefixConfig, verbs []string) *exec.Cmd {
+	var commandToRun = exec.Command(r.winetricksProgram(), append([]string{"-q"}, verbs...)...)
+	var env = r.commandEnv(targetExe, prefixConfig)
+	if targetExe.Prefix == "" {
+		// winetricks has a default prefix of its own
+		env = append(env, "WINEPREFIX="+r.prefixPath(""))
+	}
+	commandToRun.Env = append(append(os.Environ(), env...), "WINE="+r.Program)
+	commandToRun.Stdin = os.Stdin
+	commandToRun.Stdout = os.Stdout
+	commandToRun.Stderr = os.Stderr
+	return commandToRun
+}
+
+// add verbs to the ones recorded for a prefix, leaving
+// out options and the verbs already recorded
+func recordTricks(list []string, verbs []string) (retList []string) {
+	retList = append(retList, list...)
+
+	for _, verb := range verbs {
+		if strings.HasPrefix(verb, "-") {
+			continue
+		}
+
+		var recorded bool
+		for _, known := range retList {
+			recorded = recorded || known == verb
+		}
+		if !recorded {
+			retList = append(retList, verb)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestWinetricksCommand(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamRunner Runner
+		ParamExe    Exe
+		ParamConfig PrefixConfig
+		ParamVerbs  []string
+	}{
+		{
+			Description: "winetricks from the path",
+			Expected:    "[winetricks -q corefonts vcrun2019] [WINEPREFIX=/prefixes/games WINE=wine]",
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{Prefix: "/prefixes/games"},
+			ParamVerbs:  []string{"corefonts", "vcrun2019"},
+		},
+		{
+			Description: "winetricks set in the config",
+			Expected:    "[/opt/winetricks -q d3dx9] [WINEPREFIX=/prefixes/games WINE=wine-staging]",
+			ParamRunner: Runner{Program: "wine-staging", Winetricks: "/opt/winetricks"},
+			ParamExe:    Exe{Prefix: "/prefixes/games"},
+			ParamVerbs:  []string{"d3dx9"},
+		},
+		{
+			Description: "default prefix",
+			Expected:    "[winetricks -q d3dx9] [WINEPREFIX=" + Runner{}.prefixPath("") + " WINE=wine]",
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{},
+			ParamVerbs:  []string{"d3dx9"},
+		},
+		{
+			Description: "dll overrides of the entry and its prefix",
+			Expected: "[winetricks -q --force vcrun2019] [WINEPREFIX=/prefixes/games " +
+				"WINEDLLOVERRIDES=d3d9=n,b;dxgi=n WINE=wine]",
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{Prefix: "/prefixes/games", Dlls: []DllOverride{{Dll: "d3d9", Order: "n,b"}}},
+			ParamConfig: PrefixConfig{Dlls: []DllOverride{{Dll: "dxgi", Order: "n"}}},
+			ParamVerbs:  []string{"--force", "vcrun2019"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var commandToRun = testCase.ParamRunner.winetricksCommand(testCase.ParamExe, testCase.ParamConfig, testCase.ParamVerbs)
+			var gotten = fmt.Sprint(commandToRun.Args, " ", commandToRun.Env[len(os.Environ()):])
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestRecordTricks(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamList  []string
+		ParamVerbs []string
+	}{
+		{
+			Description: "first verbs",
+			Expected:    []string{"corefonts", "vcrun2019"},
+			ParamList:   nil,
+			ParamVerbs:  []string{"corefonts", "vcrun2019"},
+		},
+		{
+			Description: "verbs already recorded and options",
+			Expected:    []string{"corefonts", "win10", "renderer=vulkan"},
+			ParamList:   []string{"corefonts"},
+			ParamVerbs:  []string{"--force", "corefonts", "win10", "renderer=vulkan"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = recordTricks(testCase.ParamList, testCase.ParamVerbs)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"syscall"
+	"time"
+)
+
+// the ways of waiting for a program run without forking, for the
+// process only, for the processes it started as well or for every
+// windows process of its prefix to end (for launchers starting
+// the real program and ending right away)
+var waitStrategies = []string{"process", "tree", "wineserver"}
+
+// check if a wait strategy is one of the known ones
+func isWaitStrategy(value string) bool {
+	for _, strategy := range waitStrategies {
+		if strategy == value {
+			return true
+		}
+	}
+	return false
+}
+
+// get the wait strategy for an exe, its own or else the one
+// of the config, waiting for the process only if none is set
+func (r Runner) waitStrategy(targetExe Exe) string {
+	switch {
+	case targetExe.Wait != "":
+		return targetExe.Wait
+	case r.Wait != "":
+		return r.Wait
+	default:
+		return "process"
+	}
+}
+
+// wait after the process of a program ended for what else
+// the strategy says to wait for
+func (r Runner) waitAfter(strategy string, pid int, prefixDir string) error {
+	switch strategy {
+	case "tree":
+		// the processes it started are in its process group
+		for {
+			if killErr := syscall.Kill(-pid, 0); killErr == syscall.ESRCH {
+				return nil
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+
+	case "wineserver":
+		// the wineserver ends once no process of the prefix is left
+		return r.waitWineserver(prefixDir)
+	}
+
+	return nil
+}
+
+// wait for the wineserver of a prefix to end, which is when it
+// has written the registry of the prefix back to its files
+func (r Runner) waitWineserver(prefixDir string) error {
+	var waitServer = exec.Command(r.wineserverProgram(), "-w")
+	waitServer.Env = append(os.Environ(), "WINEPREFIX="+prefixDir)
+	if waitErr := waitServer.Run(); waitErr != nil {
+		return fmt.Errorf("could not wait for %s: %s", r.wineserverProgram(), waitErr.Error())
+	}
+	return nil
+}
+
+// get the dir the wineserver of a prefix keeps its socket and lock
+// file in, which wine names after the device and inode of the prefix
+func wineserverDir(prefixDir string) (ret string, retErr error) {
+	var info, statErr = os.Stat(prefixDir)
+	if statErr != nil {
+		return ret, statErr
+	}
+
+	var stat, isStat = info.Sys().(*syscall.Stat_t)
+	if !isStat {
+		return ret, fmt.Errorf("could not get inode of %s", prefixDir)
+	}
+	return fmt.Sprintf("/tmp/.wine-%d/server-%x-%x", os.Getuid(), uint64(stat.Dev), uint64(stat.Ino)), nil
+}
+
+// check if the wineserver of a prefix runs, which holds a write lock
+// on the lock file in its dir for as long as it does
+func prefixRunning(prefixDir string) bool {
+	var serverDir, dirErr = wineserverDir(prefixDir)
+	if dirErr != nil {
+		return false
+	}
+
+	var lockFile, openErr = os.OpenFile(path.Join(serverDir, "lock"), os.O_RDWR, 0)
+	if openErr != nil {
+		return false
+	}
+	defer lockFile.Close()
+
+	var lock = syscall.Flock_t{Type: syscall.F_WRLCK}
+	if lockErr := syscall.FcntlFlock(lockFile.Fd(), syscall.F_GETLK, &lock); lockErr != nil {
+		return false
+	}
+	return lock.Type != syscall.F_UNLCK
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestWaitStrategy(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    string
+
+		ParamRunner Runner
+		ParamExe    Exe
+	}{
+		{
+			Description: "nothing set",
+			Expected:    "process",
+			ParamRunner: Runner{},
+			ParamExe:    Exe{},
+		},
+		{
+			Description: "set in the config",
+			Expected:    "wineserver",
+			ParamRunner: Runner{Wait: "wineserver"},
+			ParamExe:    Exe{},
+		},
+		{
+			Description: "set for the entry",
+			Expected:    "tree",
+			ParamRunner: Runner{Wait: "wineserver"},
+			ParamExe:    Exe{Wait: "tree"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamRunner.waitStrategy(testCase.ParamExe)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestRunFromListWait(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		ExpectedMin time.Duration
+		ExpectedMax time.Duration
+
+		ParamWait string
+	}{
+		{
+			Description: "waiting for the process only",
+			ExpectedMin: 0,
+			ExpectedMax: 300 * time.Millisecond,
+			ParamWait:   "process",
+		},
+		{
+			Description: "waiting for what it started",
+			ExpectedMin: 500 * time.Millisecond,
+			ExpectedMax: 5 * time.Second,
+			ParamWait:   "tree",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			// the script stands in for a launcher starting a program
+			var rnr = Runner{
+				Program:     "sh",
+				ProgramArgs: "-c",
+				List: []Exe{
+					{Name: "Launcher", Path: "sleep 0.5 >/dev/null 2>&1 &", Wait: testCase.ParamWait},
+				},
+			}
+
+			var gotten, gottenErr = rnr.runFromList(1, false)
+
+			if gotten.Duration < testCase.ExpectedMin || gotten.Duration > testCase.ExpectedMax {
+				errorExpGot(t, testCase.ExpectedMin, gotten.Duration, false)
+			}
+
+			if gottenErr != nil {
+				errorExpGot(t, nil, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestPrefixRunning(t *testing.T) {
+	// make testing directory
+	var prefixDir = path.Join(TestDir, "prefix")
+	os.MkdirAll(prefixDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var serverDir, dirErr = wineserverDir(prefixDir)
+	if dirErr != nil {
+		t.Fatalf("could not get server dir: %s", dirErr.Error())
+	}
+	defer os.RemoveAll(serverDir)
+
+	var testTable = []struct {
+		Description string
+		Expected    bool
+
+		ParamLockFile bool
+		ParamLocked   bool
+	}{
+		{
+			Description: "no server dir",
+			Expected:    false,
+		},
+		{
+			Description:   "lock file left by an ended server",
+			Expected:      false,
+			ParamLockFile: true,
+		},
+		{
+			Description:   "lock held by a running server",
+			Expected:      true,
+			ParamLockFile: true,
+			ParamLocked:   true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			os.RemoveAll(serverDir)
+			if testCase.ParamLockFile {
+				os.MkdirAll(serverDir, 0700)
+				os.WriteFile(path.Join(serverDir, "lock"), []byte{}, 0600)
+			}
+
+			if testCase.ParamLocked {
+				var server = exec.Command(os.Args[0], "--hold-lock", path.Join(serverDir, "lock"))
+				server.Start()
+				defer server.Wait()
+				defer server.Process.Kill()
+				// give it the time to take the lock
+				time.Sleep(500 * time.Millisecond)
+			}
+
+			var gotten = prefixRunning(prefixDir)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
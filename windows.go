@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// the windows versions wine can report
+var windowsVersions = []string{
+	"win11", "win10", "win81", "win8", "win2008r2", "win7", "win2008", "vista",
+	"win2003", "winxp64", "winxp", "win2k", "nt40", "winme", "win98", "win95",
+	"nt351", "win31", "win30", "win20",
+}
+
+// sizes of virtual desktops (width x height)
+var desktopSizePattern = regexp.MustCompile(`^[1-9][0-9]*x[1-9][0-9]*$`)
+
+// check if a windows version is one wine knows
+func isWindowsVersion(value string) bool {
+	for _, version := range windowsVersions {
+		if strings.EqualFold(version, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// check if a virtual desktop size is given as width x height
+func isDesktopSize(value string) bool {
+	return desktopSizePattern.MatchString(value)
+}
+
+// get the registry tweaks making wine report the windows
+// version of an exe, set for that exe only through the
+// app defaults of wine so the rest of the prefix is left as is
+func (e Exe) versionTweaks() []RegTweak {
+	if e.Windows == "" {
+		return nil
+	}
+
+	return []RegTweak{
+		{
+			Key:  `HKCU\Software\Wine\AppDefaults\` + path.Base(e.Path),
+			Name: "Version",
+			Data: regData(e.Windows),
+		},
+	}
+}
+
+// get the name of the virtual desktop of an exe, which
+// is its file name without the extension
+func (e Exe) desktopName() string {
+	// the path can be a windows one
+	var base = e.Path[strings.LastIndexAny(e.Path, `\/`)+1:]
+	return strings.TrimSuffix(base, path.Ext(base))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCommandArgs(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamRunner Runner
+		ParamExe    Exe
+	}{
+		{
+			Description: "plain entry",
+			Expected:    []string{"wine", "/games/Game.exe"},
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{Name: "Game", Path: "/games/Game.exe"},
+		},
+		{
+			Description: "entry with program arguments and a windows version",
+			Expected:    []string{"wine", "--debug", "/games/Game.exe"},
+			ParamRunner: Runner{Program: "wine", ProgramArgs: "--debug"},
+			ParamExe:    Exe{Name: "Game", Path: "/games/Game.exe", Windows: "winxp"},
+		},
+		{
+			Description: "entry in a virtual desktop",
+			Expected:    []string{"wine", "explorer", "/desktop=Game,1024x768", "start", "/wait", "/unix", "/games/Game.exe"},
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{Name: "Game", Path: "/games/Game.exe", Desktop: "1024x768"},
+		},
+		{
+			Description: "entry with a windows path in a virtual desktop",
+			Expected:    []string{"wine", "explorer", "/desktop=Game,1024x768", `C:\Games\Game.exe`},
+			ParamRunner: Runner{Program: "wine"},
+			ParamExe:    Exe{Name: "Game", Path: `C:\Games\Game.exe`, Desktop: "1024x768"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamRunner.commandArgs(testCase.ParamExe)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestVersionTweaks(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []RegTweak
+
+		ParamExe Exe
+	}{
+		{
+			Description: "no version",
+			Expected:    nil,
+			ParamExe:    Exe{Path: "/games/Game.exe"},
+		},
+		{
+			Description: "version for the exe only",
+			Expected: []RegTweak{
+				{Key: `HKCU\Software\Wine\AppDefaults\Game.exe`, Name: "Version", Data: `"win7"`},
+			},
+			ParamExe: Exe{Path: "/games/Game.exe", Windows: "win7"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamExe.versionTweaks()
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestExeSetAttributeWindows(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    Exe
+
+		ParamKey   string
+		ParamValue string
+	}{
+		{
+			Description: "known version",
+			Expected:    Exe{Windows: "winxp"},
+			ParamKey:    "Windows",
+			ParamValue:  "WinXP",
+		},
+		{
+			Description: "unknown version",
+			Expected:    Exe{},
+			ParamKey:    "Windows",
+			ParamValue:  "win12",
+		},
+		{
+			Description: "desktop size",
+			Expected:    Exe{Desktop: "800x600"},
+			ParamKey:    "Desktop",
+			ParamValue:  "800x600",
+		},
+		{
+			Description: "broken desktop size",
+			Expected:    Exe{},
+			ParamKey:    "Desktop",
+			ParamValue:  "800",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten Exe
+			gotten.setAttribute(testCase.ParamKey, testCase.ParamValue)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
+
+func TestCommandEnv(t *testing.T) {
+	var testTable = []struct {
+		Description string
+		Expected    []string
+
+		ParamRunner Runner
+		ParamExe    Exe
+		ParamConfig PrefixConfig
+	}{
+		{
+			Description: "entry in the default prefix",
+			Expected:    nil,
+			ParamRunner: Runner{},
+			ParamExe:    Exe{Path: "/games/Game.exe"},
+		},
+		{
+			Description: "entry with a prefix and dll overrides",
+			Expected:    []string{"WINEPREFIX=/prefixes/games", "WINEDLLOVERRIDES=d3d9=n;dxgi=n,b"},
+			ParamRunner: Runner{PrefixDir: "/prefixes", Dlls: []DllOverride{{Dll: "d3d9", Order: "b"}}},
+			ParamExe:    Exe{Path: "/games/Game.exe", Prefix: "games", Dlls: []DllOverride{{Dll: "dxgi", Order: "n,b"}}},
+			ParamConfig: PrefixConfig{Dlls: []DllOverride{{Dll: "d3d9", Order: "n"}}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten = testCase.ParamRunner.commandEnv(testCase.ParamExe, testCase.ParamConfig)
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(gotten) {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// read the drive links of a prefix into a map of
+// drive letters (like "c:") and the dirs they point to
+func readDosDevices(prefixDir string) (ret map[string]string, retErr error) {
+	var devicesDir = path.Join(prefixDir, "dosdevices")
+
+	var entries, readErr = ioutil.ReadDir(devicesDir)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	ret = make(map[string]string)
+	for _, entry := range entries {
+		var name = strings.ToLower(entry.Name())
+
+		// only drives, not devices like com1 or raw drives like d::
+		if len(name) != 2 || name[1] != ':' || name[0] < 'a' || name[0] > 'z' {
+			continue
+		}
+
+		var target, linkErr = os.Readlink(path.Join(devicesDir, entry.Name()))
+		if linkErr != nil {
+			continue
+		}
+
+		// relative links are relative to the dosdevices dir
+		if !path.IsAbs(target) {
+			target = path.Join(devicesDir, target)
+		}
+		ret[name] = path.Clean(target)
+	}
+
+	return
+}
+
+// check if a path is a windows one (starting with a drive letter)
+func isWindowsPath(str string) bool {
+	if len(str) < 2 || str[1] != ':' {
+		return false
+	}
+	var letter = strings.ToLower(str[:1])[0]
+	return letter >= 'a' && letter <= 'z' && (len(str) == 2 || str[2] == '\\' || str[2] == '/')
+}
+
+// convert a windows path to the unix one it stands for in a prefix,
+// names are looked up ignoring case as windows does and the ones
+// not found are kept as they are given
+func unixFromWindows(prefixDir string, winPath string) (string, error) {
+	if !isWindowsPath(winPath) {
+		return "", fmt.Errorf("%s: not a windows path", winPath)
+	}
+
+	var drives, drivesErr = readDosDevices(prefixDir)
+	if drivesErr != nil {
+		return "", drivesErr
+	}
+
+	var drive = strings.ToLower(winPath[:2])
+	var ret, found = drives[drive]
+	if !found {
+		return "", fmt.Errorf("%s: drive %s is not mapped in %s", winPath, drive, prefixDir)
+	}
+
+	// go through the names one by one
+	var names = strings.FieldsFunc(winPath[2:], func(r rune) bool {
+		return r == '\\' || r == '/'
+	})
+
+	for i, name := range names {
+		// an exact match needs no searching
+		if _, statErr := os.Lstat(path.Join(ret, name)); statErr == nil {
+			ret = path.Join(ret, name)
+			continue
+		}
+
+		var entries, readErr = ioutil.ReadDir(ret)
+		var matched bool
+		if readErr == nil {
+			for _, entry := range entries {
+				if strings.EqualFold(entry.Name(), name) {
+					ret = path.Join(ret, entry.Name())
+					matched = true
+					break
+				}
+			}
+		}
+
+		// the rest can't exist so keep it as given
+		if !matched {
+			ret = path.Join(append([]string{ret}, names[i:]...)...)
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+// convert a unix path to the windows one it is seen as in a prefix,
+// going through the drive that is closest to the path
+func windowsFromUnix(prefixDir string, unixPath string) (string, error) {
+	var drives, drivesErr = readDosDevices(prefixDir)
+	if drivesErr != nil {
+		return "", drivesErr
+	}
+
+	var absPath, absErr = filepath.Abs(unixPath)
+	if absErr != nil {
+		return "", absErr
+	}
+	absPath = resolveExisting(absPath)
+
+	// compare against where the drives really are
+	var targets = make(map[string]string)
+	var letters []string
+	for letter, target := range drives {
+		var absTarget, _ = filepath.Abs(target)
+		targets[letter] = resolveExisting(absTarget)
+		letters = append(letters, letter)
+	}
+
+	// look at the longest drive targets first so that
+	// c: wins over z: for paths inside the prefix
+	sort.Slice(letters, func(i, j int) bool {
+		var lenI, lenJ = len(targets[letters[i]]), len(targets[letters[j]])
+		if lenI != lenJ {
+			return lenI > lenJ
+		}
+		return letters[i] < letters[j]
+	})
+
+	for _, letter := range letters {
+		var target = targets[letter]
+
+		var relative, relErr = filepath.Rel(target, absPath)
+		if relErr != nil || relative == ".." || strings.HasPrefix(relative, "../") {
+			continue
+		}
+
+		var ret = strings.ToUpper(letter) + `\`
+		if relative != "." {
+			ret += strings.ReplaceAll(relative, "/", `\`)
+		}
+		return ret, nil
+	}
+
+	return "", fmt.Errorf("%s: not reachable from any drive of %s", unixPath, prefixDir)
+}
+
+// resolve the links in the part of a path that exists
+// and keep the part that does not as it is
+func resolveExisting(pathToResolve string) string {
+	var existing, rest = path.Clean(pathToResolve), ""
+
+	for {
+		if resolved, evalErr := filepath.EvalSymlinks(existing); evalErr == nil {
+			return path.Join(resolved, rest)
+		}
+
+		// stop at the root
+		var parent = path.Dir(existing)
+		if parent == existing {
+			return path.Clean(pathToResolve)
+		}
+
+		rest = path.Join(path.Base(existing), rest)
+		existing = parent
+	}
+}
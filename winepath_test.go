@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// make a prefix with a c drive, a mapped d drive, z as root
+// and some devices that are not drives
+func makeTestPrefix(prefixDir string) {
+	os.MkdirAll(prefixDir+"/drive_c/Program Files/Flap", 0755)
+	os.WriteFile(prefixDir+"/drive_c/Program Files/Flap/Flap.exe", []byte{}, 0755)
+	os.MkdirAll(prefixDir+"/dosdevices", 0755)
+	os.MkdirAll(TestDir+"/cdrom", 0755)
+
+	var cdrom, _ = os.Getwd()
+	os.Symlink("../drive_c", prefixDir+"/dosdevices/c:")
+	os.Symlink(cdrom+"/"+TestDir+"/cdrom", prefixDir+"/dosdevices/d:")
+	os.Symlink("/dev/sr0", prefixDir+"/dosdevices/d::")
+	os.Symlink("/dev/ttyS0", prefixDir+"/dosdevices/com1")
+	os.Symlink("/", prefixDir+"/dosdevices/z:")
+}
+
+func TestReadDosDevices(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeTestPrefix(prefixDir)
+
+	var gotten, gottenErr = readDosDevices(prefixDir)
+	var expected = fmt.Sprintf("map[c::%s d::%s z::/]", inTestDir("prefix/drive_c"), gotten["d:"])
+
+	if fmt.Sprint(gotten) != expected {
+		errorExpGot(t, expected, gotten, false)
+	}
+
+	if gottenErr != nil {
+		errorExpGot(t, nil, gottenErr, true)
+	}
+}
+
+func TestUnixFromWindows(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeTestPrefix(prefixDir)
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+		ExpectedErr error
+
+		ParamPath string
+	}{
+		{
+			Description: "path with different case than on disk",
+			Expected:    inTestDir("prefix/drive_c/Program Files/Flap/Flap.exe"),
+			ExpectedErr: nil,
+			ParamPath:   `c:\PROGRAM FILES\flap\flap.EXE`,
+		},
+		{
+			Description: "path partly not existing",
+			Expected:    inTestDir("prefix/drive_c/Program Files/Saves/slot1"),
+			ExpectedErr: nil,
+			ParamPath:   `C:\program files\Saves\slot1`,
+		},
+		{
+			Description: "path in the root drive with forward slashes",
+			Expected:    "/etc/hosts",
+			ExpectedErr: nil,
+			ParamPath:   `Z:/etc/hosts`,
+		},
+		{
+			Description: "drive that is not mapped",
+			Expected:    "",
+			ExpectedErr: fmt.Errorf(`G:\game.exe: drive g: is not mapped in %s`, prefixDir),
+			ParamPath:   `G:\game.exe`,
+		},
+		{
+			Description: "path that is not a windows one",
+			Expected:    "",
+			ExpectedErr: fmt.Errorf("/etc/hosts: not a windows path"),
+			ParamPath:   "/etc/hosts",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = unixFromWindows(prefixDir, testCase.ParamPath)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}
+
+func TestWindowsFromUnix(t *testing.T) {
+	os.MkdirAll(TestDir, 0755)
+	defer os.RemoveAll(TestDir)
+
+	var prefixDir = inTestDir("prefix")
+	makeTestPrefix(prefixDir)
+
+	var testTable = []struct {
+		Description string
+		Expected    string
+		ExpectedErr error
+
+		ParamPath string
+	}{
+		{
+			Description: "path in the c drive",
+			Expected:    `C:\Program Files\Flap\Flap.exe`,
+			ExpectedErr: nil,
+			ParamPath:   inTestDir("prefix/drive_c/Program Files/Flap/Flap.exe"),
+		},
+		{
+			Description: "path through the drive links",
+			Expected:    `C:\Program Files\Flap\Flap.exe`,
+			ExpectedErr: nil,
+			ParamPath:   inTestDir("prefix/dosdevices/c:/Program Files/Flap/Flap.exe"),
+		},
+		{
+			Description: "path in a mapped drive that does not exist",
+			Expected:    `D:\setup\setup.exe`,
+			ExpectedErr: nil,
+			ParamPath:   inTestDir("cdrom/setup/setup.exe"),
+		},
+		{
+			Description: "path outside the prefix",
+			Expected:    `Z:\etc\hosts`,
+			ExpectedErr: nil,
+			ParamPath:   "/etc/hosts",
+		},
+		{
+			Description: "drive root",
+			Expected:    `D:\`,
+			ExpectedErr: nil,
+			ParamPath:   inTestDir("cdrom"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var gotten, gottenErr = windowsFromUnix(prefixDir, testCase.ParamPath)
+
+			if testCase.Expected != gotten {
+				errorExpGot(t, testCase.Expected, gotten, false)
+			}
+
+			if equalErrorList(t, []error{testCase.ExpectedErr}, []error{gottenErr}) == false {
+				errorExpGot(t, testCase.ExpectedErr, gottenErr, true)
+			}
+		})
+	}
+}